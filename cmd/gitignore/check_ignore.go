@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+)
+
+////////////////////////////////////////////////////////////
+
+// checkIgnore mirrors `git check-ignore`. Paths are matched against the work
+// tree containing `dir`, with every ".gitignore" and exclude file git would
+// read, or against a single ignore file given with --file.
+func checkIgnore(args []string, dir string, stdin io.Reader, stdout, stderr io.Writer) int {
+	var quiet, verbose, nonMatching, useStdin, nulTerminated bool
+	var file string
+
+	fs := flag.NewFlagSet("check-ignore", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.BoolVar(&quiet, "q", false, "suppress progress reporting")
+	fs.BoolVar(&quiet, "quiet", false, "suppress progress reporting")
+	fs.BoolVar(&verbose, "v", false, "be verbose")
+	fs.BoolVar(&verbose, "verbose", false, "be verbose")
+	fs.BoolVar(&nonMatching, "n", false, "show non-matching input paths")
+	fs.BoolVar(&nonMatching, "non-matching", false, "show non-matching input paths")
+	fs.BoolVar(&useStdin, "stdin", false, "read file names from stdin")
+	fs.BoolVar(&nulTerminated, "z", false, "terminate input and output records by a NUL character")
+	fs.StringVar(&file, "f", "", "match against this ignore file only")
+	fs.StringVar(&file, "file", "", "match against this ignore file only")
+	paths, err := parseArgs(fs, args)
+	if err != nil {
+		return exitFatal
+	}
+
+	fatal := func(format string, a ...interface{}) int {
+		fmt.Fprintf(stderr, "fatal: "+format+"\n", a...)
+		return exitFatal
+	}
+	switch {
+	case useStdin && len(paths) > 0:
+		return fatal("cannot specify pathnames with --stdin")
+	case nulTerminated && !useStdin:
+		return fatal("-z only makes sense with --stdin")
+	case !useStdin && len(paths) == 0:
+		return fatal("no path specified")
+	case quiet && verbose:
+		return fatal("cannot have both --quiet and --verbose")
+	case quiet && len(paths) != 1:
+		return fatal("--quiet is only valid with a single pathname")
+	case nonMatching && !verbose:
+		return fatal("--non-matching is only valid with --verbose")
+	}
+
+	c, err := newChecker(dir, file)
+	if err != nil {
+		return fatal("%v", err)
+	}
+
+	w := bufio.NewWriter(stdout)
+	defer w.Flush()
+	ignored := 0
+	check := func(p string) error {
+		res, err := c.check(p)
+		if err != nil {
+			return err
+		}
+		// Like git, the verbose output also shows the negated patterns
+		// which re-include a path, and counts them as matches.
+		ip := res.Pattern
+		if !verbose && !res.Ignored() {
+			ip = nil
+		}
+		if ip != nil {
+			ignored++
+		}
+		if !quiet && (ip != nil || nonMatching) {
+			c.output(w, p, ip, verbose, nulTerminated)
+		}
+		return nil
+	}
+
+	if useStdin {
+		scanner := bufio.NewScanner(stdin)
+		if nulTerminated {
+			scanner.Split(scanNul)
+		}
+		for scanner.Scan() {
+			if err := check(scanner.Text()); err != nil {
+				w.Flush()
+				return fatal("%v", err)
+			}
+			// Flush after each path, so that the command can be used as a
+			// coprocess.
+			w.Flush()
+		}
+		if err := scanner.Err(); err != nil {
+			return fatal("%v", err)
+		}
+	} else {
+		for _, p := range paths {
+			if err := check(p); err != nil {
+				w.Flush()
+				return fatal("%v", err)
+			}
+		}
+	}
+
+	if ignored > 0 {
+		return exitMatch
+	}
+	return exitNoMatch
+}
+
+// scanNul is a bufio.SplitFunc for NUL terminated records.
+func scanNul(data []byte, atEOF bool) (int, []byte, error) {
+	if i := bytes.IndexByte(data, 0); i >= 0 {
+		return i + 1, data[:i], nil
+	}
+	if atEOF && len(data) > 0 {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
+
+////////////////////////////////////////////////////////////
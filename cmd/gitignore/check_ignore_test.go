@@ -0,0 +1,202 @@
+// Implement tests for the check-ignore command
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+////////////////////////////////////////////////////////////
+
+// TestMain points $HOME at an empty directory, so that the exclude files of the
+// user running the tests are not read.
+func TestMain(m *testing.M) {
+	home, err := ioutil.TempDir("", "go-gitignore-home")
+	if err != nil {
+		panic(err)
+	}
+	os.Setenv("HOME", home)
+	os.Setenv("XDG_CONFIG_HOME", "")
+
+	code := m.Run()
+	os.RemoveAll(home)
+	os.Exit(code)
+}
+
+// Helper function to create a work tree in a temporary directory, with a
+// ".git" directory and the given files.
+func writeTestTree(t *testing.T, files map[string]string) string {
+	root, err := ioutil.TempDir("", "go-gitignore")
+	if err != nil {
+		t.Fatal(err)
+	}
+	files[".git/HEAD"] = ""
+	for name, content := range files {
+		fpath := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(fpath), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(fpath, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+// Helper function which runs the command, and returns its exit code and what
+// it wrote to stdout and stderr.
+func runCommand(dir, stdin string, args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	code := run(args, dir, strings.NewReader(stdin), &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+////////////////////////////////////////////////////////////
+
+func TestCheckIgnore(t *testing.T) {
+	root := writeTestTree(t, map[string]string{
+		".gitignore":     "*.log\n!keep.log\nbuild/\n",
+		"sub/.gitignore": "/local.txt\n",
+		"build/out.bin":  "",
+	})
+	defer os.RemoveAll(root)
+
+	code, stdout, _ := runCommand(root, "", "check-ignore", "a.log", "keep.log", "main.go", "build", "sub/local.txt")
+	assert.Equal(t, exitMatch, code, "some paths should be ignored")
+	assert.Equal(t, "a.log\nbuild\nsub/local.txt\n", stdout)
+
+	code, stdout, _ = runCommand(root, "", "check-ignore", "main.go", "build/")
+	assert.Equal(t, exitMatch, code, "build/ should be ignored")
+	assert.Equal(t, "build/\n", stdout)
+
+	code, stdout, _ = runCommand(root, "", "check-ignore", "main.go", "keep.log")
+	assert.Equal(t, exitNoMatch, code, "no path should be ignored")
+	assert.Equal(t, "", stdout)
+
+	code, stdout, _ = runCommand(filepath.Join(root, "sub"), "", "check-ignore", "local.txt", "../a.log")
+	assert.Equal(t, exitMatch, code, "paths should be relative to the working directory")
+	assert.Equal(t, "local.txt\n../a.log\n", stdout)
+}
+
+func TestCheckIgnore_Verbose(t *testing.T) {
+	root := writeTestTree(t, map[string]string{
+		".gitignore":     "*.log  \nbuild/\n",
+		"sub/.gitignore": "/local.txt\n!keep.txt\n",
+	})
+	defer os.RemoveAll(root)
+
+	code, stdout, _ := runCommand(root, "", "check-ignore", "-v", "a.log", "build/", "sub/local.txt", "main.go")
+	assert.Equal(t, exitMatch, code, "some paths should be ignored")
+	assert.Equal(t, ".gitignore:1:*.log\ta.log\n"+
+		".gitignore:2:build/\tbuild/\n"+
+		"sub/.gitignore:1:/local.txt\tsub/local.txt\n", stdout)
+
+	_, stdout, _ = runCommand(root, "", "check-ignore", "-v", "-n", "a.log", "main.go")
+	assert.Equal(t, ".gitignore:1:*.log\ta.log\n::\tmain.go\n", stdout)
+
+	// Negated patterns are shown, and count as matches
+	code, stdout, _ = runCommand(root, "", "check-ignore", "-v", "sub/keep.txt")
+	assert.Equal(t, exitMatch, code, "sub/keep.txt should be matched")
+	assert.Equal(t, "sub/.gitignore:2:!keep.txt\tsub/keep.txt\n", stdout)
+
+	code, stdout, _ = runCommand(root, "", "check-ignore", "sub/keep.txt")
+	assert.Equal(t, exitNoMatch, code, "sub/keep.txt should not be ignored")
+	assert.Equal(t, "", stdout)
+}
+
+// Validate that the options are parsed like git does, combined and after the
+// paths until a "--"
+func TestCheckIgnore_Options(t *testing.T) {
+	root := writeTestTree(t, map[string]string{
+		".gitignore":    "*.log\n",
+		"docker.ignore": "*.tmp\n",
+	})
+	defer os.RemoveAll(root)
+
+	code, stdout, _ := runCommand(root, "", "check-ignore", "-vn", "a.log", "main.go")
+	assert.Equal(t, exitMatch, code, "a.log should be ignored")
+	assert.Equal(t, ".gitignore:1:*.log\ta.log\n::\tmain.go\n", stdout)
+
+	code, stdout, _ = runCommand(root, "", "check-ignore", "a.log", "-v")
+	assert.Equal(t, exitMatch, code, "a.log should be ignored")
+	assert.Equal(t, ".gitignore:1:*.log\ta.log\n", stdout)
+
+	code, stdout, _ = runCommand(root, "", "check-ignore", "a.tmp", "-vfdocker.ignore")
+	assert.Equal(t, exitMatch, code, "a.tmp should be ignored")
+	assert.Equal(t, "docker.ignore:1:*.tmp\ta.tmp\n", stdout)
+
+	code, stdout, _ = runCommand(root, "", "check-ignore", "a.log", "--", "-v.log")
+	assert.Equal(t, exitMatch, code, "-v.log should be a path after --")
+	assert.Equal(t, "a.log\n-v.log\n", stdout)
+
+	code, _, stderr := runCommand(root, "", "check-ignore", "-vx", "a.log")
+	assert.Equal(t, exitFatal, code, "-vx should fail")
+	assert.Contains(t, stderr, "-vx", "the unknown option should be reported")
+}
+
+func TestCheckIgnore_Stdin(t *testing.T) {
+	root := writeTestTree(t, map[string]string{
+		".gitignore": "*.log\n",
+	})
+	defer os.RemoveAll(root)
+
+	code, stdout, _ := runCommand(root, "a.log\nmain.go\nb c.log\n", "check-ignore", "--stdin")
+	assert.Equal(t, exitMatch, code, "some paths should be ignored")
+	assert.Equal(t, "a.log\nb c.log\n", stdout)
+
+	code, stdout, _ = runCommand(root, "a.log\x00main.go\x00", "check-ignore", "--stdin", "-z", "-v", "-n")
+	assert.Equal(t, exitMatch, code, "some paths should be ignored")
+	assert.Equal(t, ".gitignore\x001\x00*.log\x00a.log\x00\x00\x00\x00main.go\x00", stdout)
+}
+
+func TestCheckIgnore_File(t *testing.T) {
+	root := writeTestTree(t, map[string]string{
+		".gitignore":    "*.log\n",
+		"docker.ignore": "*.tmp\n",
+	})
+	defer os.RemoveAll(root)
+
+	code, stdout, _ := runCommand(root, "", "check-ignore", "-v", "--file", "docker.ignore", "a.tmp", "a.log")
+	assert.Equal(t, exitMatch, code, "a.tmp should be ignored")
+	assert.Equal(t, "docker.ignore:1:*.tmp\ta.tmp\n", stdout)
+}
+
+func TestCheckIgnore_Quiet(t *testing.T) {
+	root := writeTestTree(t, map[string]string{
+		".gitignore": "*.log\n",
+	})
+	defer os.RemoveAll(root)
+
+	code, stdout, _ := runCommand(root, "", "check-ignore", "-q", "a.log")
+	assert.Equal(t, exitMatch, code, "a.log should be ignored")
+	assert.Equal(t, "", stdout)
+
+	code, _, _ = runCommand(root, "", "check-ignore", "-q", "a.go")
+	assert.Equal(t, exitNoMatch, code, "a.go should not be ignored")
+}
+
+func TestCheckIgnore_Errors(t *testing.T) {
+	root := writeTestTree(t, map[string]string{})
+	defer os.RemoveAll(root)
+
+	cases := map[string][]string{
+		"no path specified":                     {"check-ignore"},
+		"cannot specify pathnames with --stdin": {"check-ignore", "--stdin", "a"},
+		"-z only makes sense with --stdin":      {"check-ignore", "-z", "a"},
+		"--non-matching is only valid":          {"check-ignore", "-n", "a"},
+		"--quiet is only valid":                 {"check-ignore", "-q", "a", "b"},
+		"is outside repository":                 {"check-ignore", "../a"},
+		"is not a gitignore command":            {"check-ignored", "a"},
+	}
+	for expected, args := range cases {
+		code, _, stderr := runCommand(root, "", args...)
+		assert.Equal(t, exitFatal, code, "%v should fail", args)
+		assert.Contains(t, stderr, expected, "%v should explain the failure", args)
+	}
+}
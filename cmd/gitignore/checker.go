@@ -0,0 +1,153 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	ignore "github.com/sabhiram/go-gitignore"
+)
+
+////////////////////////////////////////////////////////////
+
+// matcher is implemented by ignore.GitIgnore and ignore.Repository.
+type matcher interface {
+	ignore.ResultIgnoreParser
+	Explain(f string, isDir bool) ignore.Explanation
+}
+
+// checker matches the paths given to the commands.
+type checker struct {
+	// dir is the working directory the paths are relative to.
+	dir string
+
+	// root is the directory the matcher expects paths to be relative to.
+	root string
+
+	m matcher
+}
+
+// newChecker returns a checker for the work tree containing `dir`, or for the
+// ignore file `file` relative to `dir` if it is set.
+func newChecker(dir, file string) (*checker, error) {
+	if file != "" {
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(dir, file)
+		}
+		gi, err := ignore.CompileIgnoreFile(file)
+		if err != nil {
+			return nil, err
+		}
+		return &checker{dir: dir, root: dir, m: gi}, nil
+	}
+
+	root := findWorkTree(dir)
+	repo, err := ignore.LoadRepository(root)
+	if err != nil {
+		return nil, err
+	}
+	return &checker{dir: dir, root: root, m: repo}, nil
+}
+
+// findWorkTree returns the closest directory above `dir` which contains a
+// ".git" directory or file, or `dir` itself if there is none.
+func findWorkTree(dir string) string {
+	for d := dir; ; {
+		if _, err := os.Stat(filepath.Join(d, ".git")); err == nil {
+			return d
+		}
+		parent := filepath.Dir(d)
+		if parent == d {
+			return dir
+		}
+		d = parent
+	}
+}
+
+// check returns the verdict for the path `p`.
+func (c *checker) check(p string) (ignore.MatchResult, error) {
+	rel, isDir, err := c.resolve(p)
+	if err != nil {
+		return ignore.MatchResult{}, err
+	}
+	return c.m.Match(rel, isDir), nil
+}
+
+// explain returns the trace of the verdict for the path `p`.
+func (c *checker) explain(p string) (ignore.Explanation, error) {
+	rel, isDir, err := c.resolve(p)
+	if err != nil {
+		return ignore.Explanation{}, err
+	}
+	return c.m.Explain(rel, isDir), nil
+}
+
+// resolve returns the slash separated path the matcher expects for the path
+// `p`, and whether it is a directory. A path is a directory if it ends with a
+// slash, or is a directory on disk.
+func (c *checker) resolve(p string) (string, bool, error) {
+	if p == "" {
+		return "", false, errors.New("empty string is not a valid pathspec")
+	}
+	abs := p
+	if !filepath.IsAbs(abs) {
+		abs = filepath.Join(c.dir, p)
+	}
+	rel, err := filepath.Rel(c.root, abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false, fmt.Errorf("%s: '%s' is outside repository at '%s'", p, p, c.root)
+	}
+
+	isDir := strings.HasSuffix(p, "/")
+	if !isDir {
+		if info, err := os.Stat(abs); err == nil {
+			isDir = info.IsDir()
+		}
+	}
+	return filepath.ToSlash(rel), isDir, nil
+}
+
+// output writes the result for the path `p` as git does. In verbose mode, the
+// pattern is prefixed with where it came from, and paths without a pattern get
+// empty fields.
+func (c *checker) output(w io.Writer, p string, ip *ignore.IgnorePattern, verbose, nulTerminated bool) {
+	if !verbose {
+		if nulTerminated {
+			fmt.Fprintf(w, "%s\x00", p)
+		} else {
+			fmt.Fprintf(w, "%s\n", p)
+		}
+		return
+	}
+
+	var source, lineNo, pattern string
+	if ip != nil {
+		source, lineNo, pattern = c.source(ip), fmt.Sprint(ip.LineNo), patternOf(ip)
+	}
+	if nulTerminated {
+		fmt.Fprintf(w, "%s\x00%s\x00%s\x00%s\x00", source, lineNo, pattern, p)
+	} else {
+		fmt.Fprintf(w, "%s:%s:%s\t%s\n", source, lineNo, pattern, p)
+	}
+}
+
+// patternOf returns the pattern `ip` as git shows it, which is its line without
+// the trailing whitespace.
+func patternOf(ip *ignore.IgnorePattern) string {
+	return strings.TrimSuffix(ip.Line, ip.Stripped)
+}
+
+// source returns the file the pattern `ip` came from, relative to the root if
+// it is inside of it.
+func (c *checker) source(ip *ignore.IgnorePattern) string {
+	if rel, err := filepath.Rel(c.root, ip.Source); err == nil && filepath.IsAbs(ip.Source) &&
+		rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return filepath.ToSlash(rel)
+	}
+	return ip.Source
+}
+
+////////////////////////////////////////////////////////////
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+)
+
+////////////////////////////////////////////////////////////
+
+// explain prints every pattern which took part in deciding each of the paths,
+// and the verdict. Like check-ignore, it exits with 0 if any path is ignored.
+func explain(args []string, dir string, stdin io.Reader, stdout, stderr io.Writer) int {
+	var file string
+
+	fs := flag.NewFlagSet("explain", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.StringVar(&file, "f", "", "match against this ignore file only")
+	fs.StringVar(&file, "file", "", "match against this ignore file only")
+	paths, err := parseArgs(fs, args)
+	if err != nil {
+		return exitFatal
+	}
+	if len(paths) == 0 {
+		fmt.Fprintln(stderr, "fatal: no path specified")
+		return exitFatal
+	}
+
+	c, err := newChecker(dir, file)
+	if err != nil {
+		fmt.Fprintf(stderr, "fatal: %v\n", err)
+		return exitFatal
+	}
+
+	w := bufio.NewWriter(stdout)
+	defer w.Flush()
+	ignored := 0
+	for _, p := range paths {
+		e, err := c.explain(p)
+		if err != nil {
+			w.Flush()
+			fmt.Fprintf(stderr, "fatal: %v\n", err)
+			return exitFatal
+		}
+		if e.Result.Ignored() {
+			ignored++
+		}
+
+		fmt.Fprintf(w, "%s: %s\n", p, e.Result.Status)
+		for _, step := range e.Steps {
+			fmt.Fprintf(w, "\t%s:%d:%s\t%s (%s)\n",
+				c.source(step.Pattern), step.Pattern.LineNo, patternOf(step.Pattern), step.Path, step.Outcome)
+		}
+	}
+
+	if ignored > 0 {
+		return exitMatch
+	}
+	return exitNoMatch
+}
+
+////////////////////////////////////////////////////////////
@@ -0,0 +1,36 @@
+// Implement tests for the explain command
+package main
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+////////////////////////////////////////////////////////////
+
+func TestExplain(t *testing.T) {
+	root := writeTestTree(t, map[string]string{
+		".gitignore":      "*.log\nlogs/\n",
+		"docs/.gitignore": "!*.log\n",
+	})
+	defer os.RemoveAll(root)
+
+	code, stdout, _ := runCommand(root, "", "explain", "docs/a.log", "logs/docs/a.log", "main.go")
+	assert.Equal(t, exitMatch, code, "logs/docs/a.log should be ignored")
+	assert.Equal(t, "docs/a.log: whitelisted\n"+
+		"\t.gitignore:1:*.log\tdocs/a.log (overridden)\n"+
+		"\tdocs/.gitignore:1:!*.log\tdocs/a.log (decided)\n"+
+		"logs/docs/a.log: ignored\n"+
+		"\t.gitignore:2:logs/\tlogs (decided)\n"+
+		"\t.gitignore:1:*.log\tlogs/docs/a.log (defeated by excluded parent)\n"+
+		"main.go: no match\n", stdout)
+
+	code, _, _ = runCommand(root, "", "explain", "docs/a.log")
+	assert.Equal(t, exitNoMatch, code, "docs/a.log should not be ignored")
+
+	code, _, stderr := runCommand(root, "", "explain")
+	assert.Equal(t, exitFatal, code, "a path should be required")
+	assert.Contains(t, stderr, "no path specified")
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"path/filepath"
+
+	ignore "github.com/sabhiram/go-gitignore"
+)
+
+////////////////////////////////////////////////////////////
+
+// lint reports the issues found in the ignore files, ".gitignore" by default.
+// It exits with 1 if there is any warning or error.
+func lint(args []string, dir string, stdin io.Reader, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("lint", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	files, err := parseArgs(fs, args)
+	if err != nil {
+		return exitFatal
+	}
+	if len(files) == 0 {
+		files = []string{ignore.GitIgnoreFile}
+	}
+
+	w := bufio.NewWriter(stdout)
+	defer w.Flush()
+	code := exitLintClean
+	for _, file := range files {
+		fpath := file
+		if !filepath.IsAbs(fpath) {
+			fpath = filepath.Join(dir, file)
+		}
+		diagnostics, err := ignore.LintFile(fpath)
+		if err != nil {
+			w.Flush()
+			fmt.Fprintf(stderr, "fatal: %v\n", err)
+			return exitFatal
+		}
+
+		for _, d := range diagnostics {
+			// Show the file the way it was given.
+			d.Source = file
+			fmt.Fprintln(w, d)
+			if d.Fix != "" {
+				fmt.Fprintf(w, "\tfix: %s\n", d.Fix)
+			}
+			if d.Severity >= ignore.SeverityWarning {
+				code = exitLintIssues
+			}
+		}
+	}
+	return code
+}
+
+////////////////////////////////////////////////////////////
@@ -0,0 +1,36 @@
+// Implement tests for the lint command
+package main
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+////////////////////////////////////////////////////////////
+
+func TestLint(t *testing.T) {
+	root := writeTestTree(t, map[string]string{
+		".gitignore":   "*.o\nbuild/\n!build/keep.txt\n*.o\n",
+		"clean.ignore": "*.o\n!keep.o\n",
+		"crlf.ignore":  "*.o\r\n",
+	})
+	defer os.RemoveAll(root)
+
+	code, stdout, _ := runCommand(root, "", "lint")
+	assert.Equal(t, exitLintIssues, code, ".gitignore should have issues")
+	assert.Equal(t, ".gitignore:3: warning: has no effect, the parent directory \"build\" is excluded by line 2 [ineffective-negation]\n"+
+		"\tfix: replace \"build/\" with \"**/build/*\", so that the directory itself is not excluded\n"+
+		".gitignore:4: warning: duplicate of line 1 [duplicate]\n"+
+		"\tfix: remove the line\n", stdout)
+
+	code, stdout, _ = runCommand(root, "", "lint", "clean.ignore", "crlf.ignore")
+	assert.Equal(t, exitLintClean, code, "infos should not be issues")
+	assert.Equal(t, "crlf.ignore:1: info: the file has CRLF line endings [crlf]\n"+
+		"\tfix: convert the file to LF line endings\n", stdout)
+
+	code, _, stderr := runCommand(root, "", "lint", "missing.ignore")
+	assert.Equal(t, exitFatal, code, "a missing file should fail")
+	assert.Contains(t, stderr, "missing.ignore")
+}
@@ -0,0 +1,139 @@
+// Command gitignore exposes the matching of the go-gitignore package to shell
+// scripts, with subcommands mirroring the git commands they replace:
+//
+//	gitignore check-ignore [options] <pathname>...
+//	gitignore check-ignore [options] --stdin
+//	gitignore explain [options] <pathname>...
+//	gitignore lint [<file>...]
+//
+// It works without git, and outside of a repository.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+////////////////////////////////////////////////////////////
+
+// Exit codes, matching the ones of git.
+const (
+	exitMatch   = 0
+	exitNoMatch = 1
+	exitFatal   = 128
+)
+
+// Exit codes of lint.
+const (
+	exitLintClean  = 0
+	exitLintIssues = 1
+)
+
+// command runs a subcommand with the `args` following its name, relative to
+// the working directory `dir`, and returns its exit code.
+type command func(args []string, dir string, stdin io.Reader, stdout, stderr io.Writer) int
+
+var commands = map[string]command{
+	"check-ignore": checkIgnore,
+	"explain":      explain,
+	"lint":         lint,
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "usage: gitignore <command> [<args>]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "commands:")
+	fmt.Fprintln(w, "   check-ignore   Debug gitignore / exclude files")
+	fmt.Fprintln(w, "   explain        Show every pattern deciding a path")
+	fmt.Fprintln(w, "   lint           Report mistakes in ignore files")
+}
+
+func run(args []string, dir string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		usage(stderr)
+		return exitFatal
+	}
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(stderr, "gitignore: '%s' is not a gitignore command\n\n", args[0])
+		usage(stderr)
+		return exitFatal
+	}
+	return cmd(args[1:], dir, stdin, stdout, stderr)
+}
+
+// parseArgs parses the options in `args` with `fs` like git does, and returns
+// the other arguments. Unlike fs.Parse, the options may follow the other
+// arguments until a "--", and the short options may be combined, as in "-vn"
+// or "-f.gitignore".
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	args = splitShortOptions(fs, args)
+	var rest []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		n := len(args) - fs.NArg()
+		if n > 0 && args[n-1] == "--" {
+			return append(rest, fs.Args()...), nil
+		}
+		if fs.NArg() == 0 {
+			return rest, nil
+		}
+		rest = append(rest, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+}
+
+// splitShortOptions splits the combined short options in `args`, which are
+// defined in `fs`, into one argument per option. The value of an option taking
+// one is split from it as well.
+func splitShortOptions(fs *flag.FlagSet, args []string) []string {
+	split := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			return append(split, args[i:]...)
+		}
+		if len(arg) < 3 || arg[0] != '-' || arg[1] == '-' || strings.Contains(arg, "=") {
+			split = append(split, arg)
+			continue
+		}
+
+		var options []string
+		for j := 1; j < len(arg); j++ {
+			f := fs.Lookup(arg[j : j+1])
+			if f == nil {
+				// Let fs.Parse report the unknown option.
+				options = []string{arg}
+				break
+			}
+			options = append(options, "-"+f.Name)
+			if b, ok := f.Value.(interface{ IsBoolFlag() bool }); !ok || !b.IsBoolFlag() {
+				if j+1 < len(arg) {
+					options = append(options, arg[j+1:])
+				} else if i+1 < len(args) {
+					i++
+					options = append(options, args[i])
+				}
+				break
+			}
+		}
+		split = append(split, options...)
+	}
+	return split
+}
+
+func main() {
+	dir, err := os.Getwd()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "fatal: %v\n", err)
+		os.Exit(exitFatal)
+	}
+	os.Exit(run(os.Args[1:], dir, os.Stdin, os.Stdout, os.Stderr))
+}
+
+////////////////////////////////////////////////////////////
@@ -0,0 +1,198 @@
+package ignore
+
+import (
+	"errors"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/scanner"
+)
+
+////////////////////////////////////////////////////////////
+
+// DockerIgnoreFile is the name of the ignore file of a Docker build context.
+const DockerIgnoreFile = ".dockerignore"
+
+// The ways a dockerPattern can match, see (*dockerPattern).compile.
+const (
+	dockerExactMatch = iota
+	dockerPrefixMatch
+	dockerSuffixMatch
+	dockerRegexpMatch
+)
+
+// dockerPattern holds what is needed to match a pattern of DialectDocker. It
+// is a port of the patterns of moby's patternmatcher package, which always
+// uses "/" as the path separator here.
+type dockerPattern struct {
+	cleaned   string
+	matchType int
+
+	// expr is the regexp equivalent to the pattern, used for the Pattern of
+	// the IgnorePattern. re is only compiled when matchType needs it.
+	expr string
+	re   *regexp.Regexp
+}
+
+// parseDockerLine parses a line of a ".dockerignore" file. Comments are only
+// recognized at the start of a line, whitespace around the pattern and its
+// "!" is dropped, and the pattern is cleaned with filepath.Clean, so that
+// leading and trailing slashes have no meaning.
+func parseDockerLine(line string) (*IgnorePattern, error) {
+	if strings.HasPrefix(line, "#") {
+		return nil, nil
+	}
+	pattern := strings.TrimSpace(line)
+	if pattern == "" {
+		return nil, nil
+	}
+
+	negate := pattern[0] == '!'
+	if negate {
+		pattern = strings.TrimSpace(pattern[1:])
+		if pattern == "" {
+			return nil, errors.New(`illegal exclusion pattern: "!"`)
+		}
+	}
+	pattern = filepath.ToSlash(filepath.Clean(pattern))
+	if len(pattern) > 1 && pattern[0] == '/' {
+		pattern = pattern[1:]
+	}
+
+	// Reject the patterns which filepath.Match considers malformed.
+	if _, err := filepath.Match(pattern, "."); err != nil {
+		return nil, err
+	}
+
+	dp := &dockerPattern{cleaned: pattern}
+	if err := dp.compile(); err != nil {
+		return nil, err
+	}
+	return &IgnorePattern{
+		Negate:   negate,
+		Stripped: line[len(strings.TrimRight(line, " \t\r\n\v\f")):],
+		glob:     pattern,
+		anchored: true,
+		docker:   dp,
+	}, nil
+}
+
+// shouldEscapeDocker reports whether `ch` has a meaning in a regexp, but not in
+// filepath.Match.
+func shouldEscapeDocker(ch rune) bool {
+	return strings.ContainsRune(".+()|{}$", ch)
+}
+
+// compile converts the pattern to a regexp, and sets the matchType to the
+// fastest way to match it.
+func (dp *dockerPattern) compile() error {
+	var sb strings.Builder
+	sb.WriteString("^")
+
+	var scan scanner.Scanner
+	scan.Init(strings.NewReader(dp.cleaned))
+	scan.Error = func(*scanner.Scanner, string) {}
+	dp.matchType = dockerExactMatch
+	for i := 0; scan.Peek() != scanner.EOF; i++ {
+		ch := scan.Next()
+		switch {
+		case ch == '*' && scan.Peek() == '*':
+			// Some flavor of "**". Treat "**/" as "**".
+			scan.Next()
+			if scan.Peek() == '/' {
+				scan.Next()
+			}
+			if scan.Peek() == scanner.EOF {
+				// A trailing "**" matches everything, like in git.
+				if dp.matchType == dockerExactMatch {
+					dp.matchType = dockerPrefixMatch
+				} else {
+					sb.WriteString(".*")
+					dp.matchType = dockerRegexpMatch
+				}
+			} else {
+				// Any number of directories, even none.
+				sb.WriteString("(.*/)?")
+				dp.matchType = dockerRegexpMatch
+			}
+			if i == 0 {
+				dp.matchType = dockerSuffixMatch
+			}
+
+		case ch == '*':
+			sb.WriteString("[^/]*")
+			dp.matchType = dockerRegexpMatch
+
+		case ch == '?':
+			sb.WriteString("[^/]")
+			dp.matchType = dockerRegexpMatch
+
+		case shouldEscapeDocker(ch):
+			sb.WriteString(`\` + string(ch))
+
+		case ch == '\\':
+			// Escape the next character. A trailing backslash is left
+			// alone, but needs to be escaped in the regexp.
+			if scan.Peek() != scanner.EOF {
+				sb.WriteString(`\` + string(scan.Next()))
+				dp.matchType = dockerRegexpMatch
+			} else {
+				sb.WriteString(`\\`)
+			}
+
+		case ch == '[' || ch == ']':
+			sb.WriteRune(ch)
+			dp.matchType = dockerRegexpMatch
+
+		default:
+			sb.WriteRune(ch)
+		}
+	}
+	sb.WriteString("$")
+
+	dp.expr = sb.String()
+	if dp.matchType != dockerRegexpMatch {
+		return nil
+	}
+	var err error
+	dp.re, err = regexp.Compile(dp.expr)
+	return err
+}
+
+// match reports whether the pattern matches the slash separated path `f`
+// itself.
+func (dp *dockerPattern) match(f string) bool {
+	switch dp.matchType {
+	case dockerExactMatch:
+		return f == dp.cleaned
+	case dockerPrefixMatch:
+		// Strip the trailing "**".
+		return strings.HasPrefix(f, dp.cleaned[:len(dp.cleaned)-2])
+	case dockerSuffixMatch:
+		// Strip the leading "**".
+		suffix := dp.cleaned[2:]
+		if strings.HasSuffix(f, suffix) {
+			return true
+		}
+		// "**/foo" matches "foo".
+		return suffix[0] == '/' && f == suffix[1:]
+	}
+	return dp.re.MatchString(f)
+}
+
+// matchesOrParentMatches reports whether the pattern matches the slash
+// separated path `f`, or any of its parent directories. Unlike git, Docker
+// lets a later exception re-include a path inside of an excluded directory.
+func (dp *dockerPattern) matchesOrParentMatches(f string) bool {
+	if dp.match(f) {
+		return true
+	}
+	for i := 0; i < len(f); i++ {
+		if f[i] == '/' && dp.match(f[:i]) {
+			return true
+		}
+	}
+	return false
+}
+
+////////////////////////////////////////////////////////////
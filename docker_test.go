@@ -0,0 +1,150 @@
+// Implement tests for the .dockerignore dialect
+package ignore
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+////////////////////////////////////////////////////////////
+
+// Helper function which compiles `lines` as a ".dockerignore" file.
+func compileDocker(t *testing.T, lines ...string) *GitIgnore {
+	object, err := Compile(WithDialect(DialectDocker), WithLines(lines...))
+	assert.Nil(t, err, "err should be nil")
+	return object
+}
+
+////////////////////////////////////////////////////////////
+
+// Validate the matching of single paths against the cases of moby's
+// patternmatcher, which Docker uses for its build contexts.
+func TestDocker_Conformance(t *testing.T) {
+	cases := []struct {
+		pattern string
+		path    string
+		match   bool
+	}{
+		{"**", "file", true},
+		{"**", "file/", true},
+		{"**/", "file", true},
+		{"**/", "file/", true},
+		{"**", "dir/file", true},
+		{"**/", "dir/file", true},
+		{"**", "dir/file/", true},
+		{"**/", "dir/file/", true},
+		{"**/**", "dir/file", true},
+		{"**/**", "dir/file/", true},
+		{"dir/**", "dir/file", true},
+		{"dir/**", "dir/file/", true},
+		{"dir/**", "dir/dir2/file", true},
+		{"dir/**", "dir/dir2/file/", true},
+		{"**/dir", "dir", true},
+		{"**/dir", "dir/file", true},
+		{"**/dir2/*", "dir/dir2/file", true},
+		{"**/dir2/*", "dir/dir2/file/", true},
+		{"**/dir2/**", "dir/dir2/dir3/file", true},
+		{"**/dir2/**", "dir/dir2/dir3/file/", true},
+		{"**file", "file", true},
+		{"**file", "dir/file", true},
+		{"**/file", "dir/file", true},
+		{"**file", "dir/dir/file", true},
+		{"**/file", "dir/dir/file", true},
+		{"**/file*", "dir/dir/file", true},
+		{"**/file*", "dir/dir/file.txt", true},
+		{"**/file*txt", "dir/dir/file.txt", true},
+		{"**/file*.txt", "dir/dir/file.txt", true},
+		{"**/file*.txt*", "dir/dir/file.txt", true},
+		{"**/**/*.txt", "dir/dir/file.txt", true},
+		{"**/**/*.txt2", "dir/dir/file.txt", false},
+		{"**/*.txt", "file.txt", true},
+		{"**/**/*.txt", "file.txt", true},
+		{"a**/*.txt", "a/file.txt", true},
+		{"a**/*.txt", "a/dir/file.txt", true},
+		{"a**/*.txt", "a/dir/dir/file.txt", true},
+		{"a/*.txt", "a/dir/file.txt", false},
+		{"a/*.txt", "a/file.txt", true},
+		{"a/*.txt**", "a/file.txt", true},
+		{"a[b-d]e", "ae", false},
+		{"a[b-d]e", "ace", true},
+		{"a[b-d]e", "aae", false},
+		{"a[^b-d]e", "aze", true},
+		{".*", ".foo", true},
+		{".*", "foo", false},
+		{"abc.def", "abcdef", false},
+		{"abc.def", "abc.def", true},
+		{"abc.def", "abcZdef", false},
+		{"abc?def", "abcZdef", true},
+		{"abc?def", "abcdef", false},
+		{"**/foo/bar", "foo/bar", true},
+		{"**/foo/bar", "dir/foo/bar", true},
+		{"**/foo/bar", "dir/dir2/foo/bar", true},
+		{"abc/**", "abc", false},
+		{"abc/**", "abc/def", true},
+		{"abc/**", "abc/def/ghi", true},
+		{"**/.foo", ".foo", true},
+		{"**/.foo", "bar.foo", false},
+		{"a(b)c/def", "a(b)c/def", true},
+		{"a(b)c/def", "a(b)c/xyz", false},
+		{"a.|)$(}+{bc", "a.|)$(}+{bc", true},
+		{"dist/proxy.py-2.4.0rc3.dev36+g08acad9-py3-none-any.whl", "dist/proxy.py-2.4.0rc3.dev36+g08acad9-py3-none-any.whl", true},
+		{"dist/*.whl", "dist/proxy.py-2.4.0rc3.dev36+g08acad9-py3-none-any.whl", true},
+	}
+	for _, c := range cases {
+		object := compileDocker(t, c.pattern)
+		assert.Equal(t, c.match, object.MatchesPath(c.path), "%q should match %q: %v", c.pattern, c.path, c.match)
+	}
+}
+
+// Validate the parsing of a ".dockerignore" file, as done by moby's
+// ignorefile.ReadAll.
+func TestDocker_Parse(t *testing.T) {
+	content := "\ufefftest1\n/test2\n/a/file/here\n\nlastfile\n# this is a comment\n! /inverted/abs/path\n!\n! \n  # not a comment\n"
+	object := compileDocker(t, strings.Split(content, "\n")...)
+
+	var globs []string
+	for _, ip := range object.patterns {
+		if ip.Negate {
+			globs = append(globs, "!"+ip.glob)
+		} else {
+			globs = append(globs, ip.glob)
+		}
+	}
+	assert.Equal(t, []string{"test1", "test2", "a/file/here", "lastfile", "!inverted/abs/path", "# not a comment"}, globs)
+
+	assert.Equal(t, 2, len(object.Warnings()), "a lone ! should be invalid")
+	assert.Equal(t, 8, object.Warnings()[0].LineNo)
+	assert.Equal(t, 9, object.Warnings()[1].LineNo)
+
+	_, err := Compile(WithDialect(DialectDocker), WithLines("["), WithStrict(true))
+	assert.NotNil(t, err, "err should be a malformed pattern")
+}
+
+// Validate that the patterns match the paths inside of the directories they
+// match, and that the last matching one decides.
+func TestDocker_Precedence(t *testing.T) {
+	object := compileDocker(t, "docs", "!docs/README.md", "**/*.tmp", "/build/", "!build/keep")
+
+	assert.Equal(t, true, object.MatchesPath("docs"), "docs should match")
+	assert.Equal(t, true, object.MatchesPath("docs/guide/intro.md"), "docs/guide/intro.md should match")
+	assert.Equal(t, false, object.MatchesPath("docs/README.md"), "docs/README.md should be re-included")
+	assert.Equal(t, false, object.MatchesPath("src/docs"), "src/docs should not match")
+	assert.Equal(t, true, object.MatchesPath("a.tmp"), "a.tmp should match")
+	assert.Equal(t, true, object.MatchesPath("a/b/c.tmp/d"), "a/b/c.tmp/d should match")
+	assert.Equal(t, true, object.MatchesPath("build/out"), "build/out should match")
+	assert.Equal(t, false, object.MatchesPath("build/keep/x"), "build/keep/x should be re-included")
+
+	object = compileDocker(t, "**", "!util/docker/web")
+	assert.Equal(t, true, object.MatchesPath("util/docker"), "util/docker should match")
+	assert.Equal(t, false, object.MatchesPath("util/docker/web/foo"), "util/docker/web/foo should be re-included")
+
+	assert.Equal(t, Whitelisted, object.Match("util/docker/web/foo", false).Status)
+	steps := object.Explain("util/docker/web/foo", false).Steps
+	assert.Equal(t, 2, len(steps), "no step should be defeated by a parent")
+	assert.Equal(t, Overridden, steps[0].Outcome)
+	assert.Equal(t, Decided, steps[1].Outcome)
+}
+
+////////////////////////////////////////////////////////////
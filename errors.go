@@ -0,0 +1,53 @@
+package ignore
+
+import (
+	"fmt"
+	"strings"
+)
+
+////////////////////////////////////////////////////////////
+
+// LineError describes a line of an ignore file which could not be compiled
+// into a pattern. Such a line never matches anything.
+type LineError struct {
+	Source string // Path of the ignore file, empty for lines passed directly
+	LineNo int    // 1-based line number within the source
+	Line   string // The line as it was read
+	Err    error  // The reason the line is invalid
+}
+
+// Error implements the error interface.
+func (e *LineError) Error() string {
+	where := fmt.Sprintf("line %d", e.LineNo)
+	if e.Source != "" {
+		where = fmt.Sprintf("%s:%d", e.Source, e.LineNo)
+	}
+	return fmt.Sprintf("%s: %v: %q", where, e.Err, e.Line)
+}
+
+// Unwrap returns the reason the line is invalid.
+func (e *LineError) Unwrap() error {
+	return e.Err
+}
+
+////////////////////////////////////////////////////////////
+
+// CompileError is returned by the strict compile functions, and lists every
+// line which could not be compiled.
+type CompileError struct {
+	Errors []*LineError
+}
+
+// Error implements the error interface.
+func (e *CompileError) Error() string {
+	msgs := make([]string, 0, len(e.Errors))
+	for _, le := range e.Errors {
+		msgs = append(msgs, le.Error())
+	}
+	if len(msgs) == 1 {
+		return msgs[0]
+	}
+	return fmt.Sprintf("%d invalid lines: %s", len(msgs), strings.Join(msgs, "; "))
+}
+
+////////////////////////////////////////////////////////////
@@ -0,0 +1,142 @@
+package ignore
+
+import (
+	"errors"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+////////////////////////////////////////////////////////////
+
+// LoadRepository is like CompileRepository, but also reads the exclude files
+// which git consults outside of the work tree, so that the result agrees with
+// `git status`. In order of precedence, below every ".gitignore" file:
+//
+//   - "$GIT_DIR/info/exclude"
+//   - the file named by core.excludesFile in the repository config, or in the
+//     global "~/.gitconfig" or "$XDG_CONFIG_HOME/git/config"
+//   - "$XDG_CONFIG_HOME/git/ignore" if core.excludesFile is not set, where
+//     $XDG_CONFIG_HOME defaults to "~/.config"
+//
+// The patterns of the exclude files are relative to `root`. Missing files are
+// skipped, and the Source of each pattern tells which file it came from. When
+// core.ignorecase is set in the same config files, the patterns match
+// regardless of case.
+func LoadRepository(root string) (*Repository, error) {
+	return LoadRepositoryTiers(root, GitTiers...)
+}
+
+// LoadRepositoryTiers is like LoadRepository, but compiles the ignore files of
+// the `tiers` like CompileRepositoryTiers. The exclude files are consulted
+// after every tier. If any tier requires git, they are only read when `root`
+// is inside of a git repository.
+func LoadRepositoryTiers(root string, tiers ...IgnoreTier) (*Repository, error) {
+	r, err := CompileRepositoryTiers(root, tiers...)
+	if err != nil {
+		return nil, err
+	}
+	if requireGit(tiers) && !r.gitRoots[""] && !r.inGit {
+		return r, nil
+	}
+
+	gitDirPath, err := findGitDir(root)
+	if err != nil {
+		return nil, err
+	}
+
+	excludePaths := []string{}
+	configPaths := []string{xdgConfigPath("config"), expandHome("~/.gitconfig")}
+	if gitDirPath != "" {
+		excludePaths = append(excludePaths, filepath.Join(gitDirPath, "info", "exclude"))
+		configPaths = append(configPaths, filepath.Join(gitDirPath, "config"))
+	}
+
+	// Later config files override the earlier ones.
+	excludesFile := xdgConfigPath("ignore")
+	ignoreCase := false
+	for _, fpath := range configPaths {
+		value, ok, err := gitConfigValue(fpath, "core", "excludesfile")
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			excludesFile = expandHome(value)
+		}
+
+		value, ok, err = gitConfigValue(fpath, "core", "ignorecase")
+		if err != nil {
+			return nil, err
+		}
+		if b, valid := parseGitBool(value); ok && valid {
+			ignoreCase = b
+		}
+	}
+	if excludesFile != "" {
+		excludePaths = append(excludePaths, excludesFile)
+	}
+
+	for _, fpath := range excludePaths {
+		gi, err := CompileIgnoreFile(fpath)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		r.excludes = append(r.excludes, gi)
+	}
+	r.SetIgnoreCase(ignoreCase)
+	return r, nil
+}
+
+// findGitDir returns the git directory holding the "info/exclude" and "config"
+// files of the work tree at `root`, or an empty string if it has none. A ".git"
+// file, as used by linked work trees and submodules, points at the git
+// directory with a "gitdir:" line. The git directory of a linked work tree
+// names the one it shares with the main work tree in its "commondir" file.
+func findGitDir(root string) (string, error) {
+	fpath := filepath.Join(root, gitDir)
+	info, err := os.Stat(fpath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+	if info.IsDir() {
+		return fpath, nil
+	}
+
+	bs, err := ioutil.ReadFile(fpath)
+	if err != nil {
+		return "", err
+	}
+	line := strings.TrimSpace(string(bs))
+	if !strings.HasPrefix(line, "gitdir:") {
+		return "", nil
+	}
+	dir := filepath.FromSlash(strings.TrimSpace(strings.TrimPrefix(line, "gitdir:")))
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(root, dir)
+	}
+	return commonGitDir(dir)
+}
+
+// commonGitDir returns the directory named by the "commondir" file of the git
+// directory `dir`, relative to it, or `dir` itself if there is no such file.
+func commonGitDir(dir string) (string, error) {
+	bs, err := ioutil.ReadFile(filepath.Join(dir, "commondir"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return dir, nil
+	} else if err != nil {
+		return "", err
+	}
+	common := filepath.FromSlash(strings.TrimSpace(string(bs)))
+	if !filepath.IsAbs(common) {
+		common = filepath.Join(dir, common)
+	}
+	return common, nil
+}
+
+////////////////////////////////////////////////////////////
@@ -0,0 +1,189 @@
+// Implement tests for `LoadRepository`
+package ignore
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+////////////////////////////////////////////////////////////
+
+// Helper function which points $HOME and $XDG_CONFIG_HOME at the given
+// directories, and returns a function restoring them.
+func setTestHome(home, xdg string) func() {
+	oldHome, oldXDG := os.Getenv("HOME"), os.Getenv("XDG_CONFIG_HOME")
+	os.Setenv("HOME", home)
+	os.Setenv("XDG_CONFIG_HOME", xdg)
+	return func() {
+		os.Setenv("HOME", oldHome)
+		os.Setenv("XDG_CONFIG_HOME", oldXDG)
+	}
+}
+
+////////////////////////////////////////////////////////////
+
+// Validate the precedence of the exclude sources, and that each match reports
+// the file it came from
+func TestLoadRepository_Precedence(t *testing.T) {
+	home := writeTestTree(t, map[string]string{
+		".config/git/ignore": "*.log\n*.tmp\n",
+	})
+	defer os.RemoveAll(home)
+	defer setTestHome(home, "")()
+
+	root := writeTestTree(t, map[string]string{
+		".gitignore":        "!notes.tmp\n",
+		".git/info/exclude": "/secret.txt\n!keep.log\n",
+	})
+	defer os.RemoveAll(root)
+
+	object, err := LoadRepository(root)
+	assert.Nil(t, err, "err should be nil")
+	assert.NotNil(t, object, "object should not be nil")
+
+	matches, how := object.MatchesPathHow("debug.log")
+	assert.Equal(t, true, matches, "debug.log should match")
+	assert.Equal(t, filepath.Join(home, ".config", "git", "ignore"), how.Source, "debug.log should match the global excludes")
+
+	matches, how = object.MatchesPathHow("secret.txt")
+	assert.Equal(t, true, matches, "secret.txt should match")
+	assert.Equal(t, filepath.Join(root, ".git", "info", "exclude"), how.Source, "secret.txt should match info/exclude")
+
+	assert.Equal(t, false, object.MatchesPath("a/secret.txt"), "a/secret.txt should not match")
+	assert.Equal(t, false, object.MatchesPath("keep.log"), "keep.log should not match")
+	assert.Equal(t, false, object.MatchesPath("notes.tmp"), "notes.tmp should not match")
+	assert.Equal(t, true, object.MatchesPath("a/other.tmp"), "a/other.tmp should match")
+}
+
+// Validate that core.excludesFile is read from the repository config first,
+// then from the global config files
+func TestLoadRepository_ExcludesFile(t *testing.T) {
+	home := writeTestTree(t, map[string]string{
+		".gitconfig":         "[core]\n\texcludesFile = ~/global.ignore\n",
+		".config/git/config": "[core]\n\texcludesFile = ~/xdg.ignore\n",
+		".config/git/ignore": "*\n",
+		"global.ignore":      "*.global\n",
+		"local.ignore":       "*.local\n",
+	})
+	defer os.RemoveAll(home)
+	defer setTestHome(home, "")()
+
+	root := writeTestTree(t, map[string]string{
+		".git/HEAD": "",
+	})
+	defer os.RemoveAll(root)
+
+	object, err := LoadRepository(root)
+	assert.Nil(t, err, "err should be nil")
+	assert.Equal(t, true, object.MatchesPath("a.global"), "a.global should match")
+	assert.Equal(t, false, object.MatchesPath("a.local"), "a.local should not match")
+
+	err = ioutil.WriteFile(filepath.Join(root, ".git", "config"), []byte("[core]\nexcludesfile = "+filepath.Join(home, "local.ignore")+"\n"), 0644)
+	assert.Nil(t, err, "err should be nil")
+
+	object, err = LoadRepository(root)
+	assert.Nil(t, err, "err should be nil")
+	assert.Equal(t, false, object.MatchesPath("a.global"), "a.global should not match")
+	assert.Equal(t, true, object.MatchesPath("a.local"), "a.local should match")
+}
+
+// Validate that $XDG_CONFIG_HOME is used when it is set
+func TestLoadRepository_XDGConfigHome(t *testing.T) {
+	home := writeTestTree(t, map[string]string{
+		".config/git/ignore": "*.home\n",
+		"xdg/git/ignore":     "*.xdg\n",
+	})
+	defer os.RemoveAll(home)
+	defer setTestHome(home, filepath.Join(home, "xdg"))()
+
+	root := writeTestTree(t, map[string]string{})
+	defer os.RemoveAll(root)
+
+	object, err := LoadRepository(root)
+	assert.Nil(t, err, "err should be nil")
+	assert.Equal(t, true, object.MatchesPath("a.xdg"), "a.xdg should match")
+	assert.Equal(t, false, object.MatchesPath("a.home"), "a.home should not match")
+}
+
+// Validate that a ".git" file pointing at the git directory is followed
+func TestLoadRepository_GitDirFile(t *testing.T) {
+	home := writeTestTree(t, map[string]string{})
+	defer os.RemoveAll(home)
+	defer setTestHome(home, "")()
+
+	root := writeTestTree(t, map[string]string{
+		"modules/sub/info/exclude": "*.sub\n",
+		"work/.git":                "gitdir: ../modules/sub\n",
+	})
+	defer os.RemoveAll(root)
+
+	object, err := LoadRepository(filepath.Join(root, "work"))
+	assert.Nil(t, err, "err should be nil")
+	assert.Equal(t, true, object.MatchesPath("a.sub"), "a.sub should match")
+}
+
+// Validate that a linked work tree reads the exclude and config files of the
+// git directory named by the "commondir" file of its own
+func TestLoadRepository_LinkedWorkTree(t *testing.T) {
+	home := writeTestTree(t, map[string]string{})
+	defer os.RemoveAll(home)
+	defer setTestHome(home, "")()
+
+	root := writeTestTree(t, map[string]string{
+		"main/.git/info/exclude":          "*.local\n",
+		"main/.git/worktrees/x/HEAD":      "",
+		"main/.git/worktrees/x/commondir": "../..\n",
+		"shared.ignore":                   "*.tmp\n",
+		"x/.git":                          "gitdir: ../main/.git/worktrees/x\n",
+	})
+	defer os.RemoveAll(root)
+	config := "[core]\n\texcludesFile = " + filepath.ToSlash(filepath.Join(root, "shared.ignore")) + "\n"
+	err := ioutil.WriteFile(filepath.Join(root, "main", ".git", "config"), []byte(config), 0644)
+	assert.Nil(t, err, "err should be nil")
+
+	object, err := LoadRepository(filepath.Join(root, "x"))
+	assert.Nil(t, err, "err should be nil")
+	assert.Equal(t, true, object.MatchesPath("a.local"), "a.local should match info/exclude")
+	_, how := object.MatchesPathHow("a.local")
+	assert.Equal(t, filepath.Join(root, "main", ".git", "info", "exclude"), how.Source)
+	assert.Equal(t, true, object.MatchesPath("a.tmp"), "a.tmp should match core.excludesFile")
+}
+
+func TestLoadRepository_InvalidRoot(t *testing.T) {
+	object, err := LoadRepository("./test_fixtures/invalid.dir")
+	assert.Nil(t, object, "object should be nil")
+	assert.NotNil(t, err, "err should be unknown file / dir")
+}
+
+// Validate that core.ignorecase is read from the repository config
+func TestLoadRepository_IgnoreCase(t *testing.T) {
+	home := writeTestTree(t, map[string]string{
+		".gitconfig":         "[core]\n\tignorecase = true\n",
+		".config/git/ignore": "*.TMP\n",
+	})
+	defer os.RemoveAll(home)
+	defer setTestHome(home, "")()
+
+	root := writeTestTree(t, map[string]string{
+		".gitignore":  "/Build/\n",
+		".git/config": "[core]\n\tignoreCase = false\n",
+	})
+	defer os.RemoveAll(root)
+
+	object, err := LoadRepository(root)
+	assert.Nil(t, err, "err should be nil")
+	assert.Equal(t, false, object.MatchesPath("build/a.o"), "build/a.o should not match")
+	assert.Equal(t, false, object.MatchesPath("a.tmp"), "a.tmp should not match")
+
+	err = ioutil.WriteFile(filepath.Join(root, ".git", "config"), []byte("[core]\n\tignorecase\n"), 0644)
+	assert.Nil(t, err, "err should be nil")
+
+	object, err = LoadRepository(root)
+	assert.Nil(t, err, "err should be nil")
+	assert.Equal(t, true, object.MatchesPath("build/a.o"), "build/a.o should match")
+	assert.Equal(t, true, object.MatchesPath("a.tmp"), "a.tmp should match")
+}
@@ -0,0 +1,180 @@
+package ignore
+
+////////////////////////////////////////////////////////////
+
+// StepOutcome tells what became of a pattern which matched a path.
+type StepOutcome int
+
+const (
+	// Decided is the outcome of the pattern which decided the verdict.
+	Decided StepOutcome = iota
+
+	// Overridden is the outcome of a pattern which matched, but was
+	// overridden by a pattern of higher precedence.
+	Overridden
+
+	// DefeatedByParent is the outcome of a pattern which matched, but had
+	// no effect since a parent directory is excluded [Rule 4].
+	DefeatedByParent
+)
+
+// String implements fmt.Stringer.
+func (o StepOutcome) String() string {
+	switch o {
+	case Decided:
+		return "decided"
+	case Overridden:
+		return "overridden"
+	case DefeatedByParent:
+		return "defeated by excluded parent"
+	}
+	return "unknown"
+}
+
+// ExplainStep is a pattern which matched a path or one of its parents.
+type ExplainStep struct {
+	// Path is the slash separated path the pattern matched, which is a
+	// parent directory of the explained path for an excluded parent.
+	Path    string
+	Pattern *IgnorePattern
+	Outcome StepOutcome
+}
+
+// Explanation is the trace of every pattern which took part in the verdict for
+// a path, as returned by Explain.
+type Explanation struct {
+	// Path is the explained path, slash separated and without a trailing
+	// slash.
+	Path  string
+	IsDir bool
+
+	// Steps lists the pattern excluding a parent directory of Path if there
+	// is one, followed by every pattern matching Path, from the lowest to the
+	// highest precedence.
+	Steps []ExplainStep
+
+	// Result is the final verdict, the same as the one of Match.
+	Result MatchResult
+}
+
+////////////////////////////////////////////////////////////
+
+// explainMatcher is implemented by the types which can list every pattern
+// matching a single path, without looking at its parents.
+type explainMatcher interface {
+	levelMatcher
+
+	// allMatches returns the patterns matching `f`, from the lowest to the
+	// highest precedence, so that the last one is the one of lastMatch.
+	allMatches(f string, isDir bool) []*IgnorePattern
+}
+
+// explain traces the verdict of `m` for the slash separated path `f`. The
+// excluded parent directories of `f` are only looked at if `parents` is set,
+// as the patterns of DialectDocker match them on their own.
+func explain(m explainMatcher, f string, isDir bool, parents bool) Explanation {
+	e := Explanation{
+		Path:   f,
+		IsDir:  isDir,
+		Result: resultOf(m.lastMatch(f, isDir)),
+	}
+	if parents {
+		e.Result = matchWithParents(m, f, isDir)
+	}
+
+	parentExcluded := false
+	for i := 0; parents && i < len(f); i++ {
+		if f[i] != '/' {
+			continue
+		}
+		if ip := m.lastMatch(f[:i], true); ip != nil && !ip.Negate {
+			e.Steps = append(e.Steps, ExplainStep{Path: f[:i], Pattern: ip, Outcome: Decided})
+			parentExcluded = true
+			break
+		}
+	}
+
+	matches := m.allMatches(f, isDir)
+	for i, ip := range matches {
+		outcome := Overridden
+		switch {
+		case parentExcluded:
+			outcome = DefeatedByParent
+		case i == len(matches)-1:
+			outcome = Decided
+		}
+		e.Steps = append(e.Steps, ExplainStep{Path: f, Pattern: ip, Outcome: outcome})
+	}
+	return e
+}
+
+////////////////////////////////////////////////////////////
+
+// Explain returns every pattern of the GitIgnore which took part in deciding
+// the path `f`, which is a directory if `isDir` is set, along with the
+// verdict. Unlike Match, it also reports the patterns which were overridden,
+// and the ones which had no effect because a parent directory is excluded.
+func (gi *GitIgnore) Explain(f string, isDir bool) Explanation {
+	rel, ok := gi.relPath(f)
+	if !ok {
+		return Explanation{Path: rel, IsDir: isDir, Result: MatchResult{Status: NoMatch}}
+	}
+	return explain(gi, rel, isDir, gi.dialect != DialectDocker)
+}
+
+// allMatches returns the patterns in the GitIgnore which match the path `f`,
+// in the order they appear.
+func (gi *GitIgnore) allMatches(f string, isDir bool) []*IgnorePattern {
+	var matches []*IgnorePattern
+	for _, ip := range gi.patterns {
+		if ip.matches(f, isDir) {
+			matches = append(matches, ip)
+		}
+	}
+	return matches
+}
+
+// Explain is like GitIgnore.Explain, and also reports the patterns of the
+// ignore files which were overridden by the ones in deeper directories.
+func (r *Repository) Explain(f string, isDir bool) Explanation {
+	rel, ok := r.relPath(f)
+	if !ok {
+		return Explanation{Path: rel, IsDir: isDir, Result: MatchResult{Status: NoMatch}}
+	}
+	return explain(r, rel, isDir, true)
+}
+
+// allMatches returns the patterns in the excludes and in the ignore files
+// from the root down to the deepest directory containing `f` which match it,
+// from the tier with the lowest precedence to the highest.
+func (r *Repository) allMatches(f string, isDir bool) []*IgnorePattern {
+	var matches []*IgnorePattern
+	for i := len(r.excludes) - 1; i >= 0; i-- {
+		matches = append(matches, r.excludes[i].allMatches(f, isDir)...)
+	}
+	for j := len(r.tiers) - 1; j >= 0; j-- {
+		t := r.tiers[j]
+		top := -1
+		if t.RequireGit {
+			var ok bool
+			if top, ok = r.gitTop(f); !ok {
+				continue
+			}
+		}
+		for i := top; i < len(f); i++ {
+			if i >= 0 && f[i] != '/' {
+				continue
+			}
+			dir, rel := "", f
+			if i >= 0 {
+				dir, rel = f[:i], f[i+1:]
+			}
+			if gi, ok := t.ignores[r.dirKey(dir)]; ok {
+				matches = append(matches, gi.allMatches(rel, isDir)...)
+			}
+		}
+	}
+	return matches
+}
+
+////////////////////////////////////////////////////////////
@@ -0,0 +1,79 @@
+// Implement tests for `Explain`
+package ignore
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+////////////////////////////////////////////////////////////
+
+// Helper function which summarizes the steps of an explanation as the line
+// numbers and outcomes of their patterns.
+func explainSteps(e Explanation) []string {
+	var steps []string
+	for _, s := range e.Steps {
+		steps = append(steps, s.Pattern.Line+" "+s.Outcome.String())
+	}
+	return steps
+}
+
+////////////////////////////////////////////////////////////
+
+func TestGitIgnore_Explain(t *testing.T) {
+	object := CompileIgnoreLines("*.log", "!keep.log", "logs/", "*.log", "!important.log")
+
+	e := object.Explain("a/keep.log", false)
+	assert.Equal(t, "a/keep.log", e.Path)
+	assert.Equal(t, Ignored, e.Result.Status, "a/keep.log should be ignored")
+	assert.Equal(t, []string{"*.log overridden", "!keep.log overridden", "*.log decided"}, explainSteps(e))
+
+	e = object.Explain("important.log", false)
+	assert.Equal(t, Whitelisted, e.Result.Status, "important.log should be whitelisted")
+	assert.Equal(t, []string{"*.log overridden", "*.log overridden", "!important.log decided"}, explainSteps(e))
+
+	e = object.Explain("logs/important.log", false)
+	assert.Equal(t, Ignored, e.Result.Status, "logs/important.log should be ignored")
+	assert.Equal(t, []string{"logs/ decided", "*.log defeated by excluded parent",
+		"*.log defeated by excluded parent", "!important.log defeated by excluded parent"}, explainSteps(e))
+	assert.Equal(t, "logs", e.Steps[0].Path, "the parent should be reported")
+	assert.Equal(t, e.Steps[0].Pattern, e.Result.Pattern, "the parent should decide")
+
+	e = object.Explain("main.go", false)
+	assert.Equal(t, NoMatch, e.Result.Status, "main.go should not be matched")
+	assert.Equal(t, 0, len(e.Steps), "main.go should not have any steps")
+}
+
+// Validate that the explanation of a ".hgignore" file agrees with Match, which
+// also ignores the contents of the excluded directories.
+func TestGitIgnore_ExplainHg(t *testing.T) {
+	object := compileHg(t, "^build$")
+	assert.Equal(t, Ignored, object.Match("build/x", false).Status, "build/x should be ignored")
+
+	e := object.Explain("build/x", false)
+	assert.Equal(t, Ignored, e.Result.Status, "build/x should be ignored")
+	assert.Equal(t, []string{"^build$ decided"}, explainSteps(e))
+	assert.Equal(t, "build", e.Steps[0].Path, "the parent should be reported")
+}
+
+func TestRepository_Explain(t *testing.T) {
+	root := writeTestTree(t, map[string]string{
+		".gitignore":      "*.txt\n",
+		"docs/.gitignore": "!*.txt\n/drafts\n",
+	})
+	defer os.RemoveAll(root)
+
+	object, err := CompileRepository(root)
+	assert.Nil(t, err, "err should be nil")
+
+	e := object.Explain("docs/a.txt", false)
+	assert.Equal(t, Whitelisted, e.Result.Status, "docs/a.txt should be whitelisted")
+	assert.Equal(t, []string{"*.txt overridden", "!*.txt decided"}, explainSteps(e))
+
+	e = object.Explain("docs/drafts/a.txt", false)
+	assert.Equal(t, Ignored, e.Result.Status, "docs/drafts/a.txt should be ignored")
+	assert.Equal(t, []string{"/drafts decided", "*.txt defeated by excluded parent",
+		"!*.txt defeated by excluded parent"}, explainSteps(e))
+}
@@ -0,0 +1,130 @@
+package ignore
+
+import (
+	"io/fs"
+	"path"
+)
+
+////////////////////////////////////////////////////////////
+
+// FilterFS returns a file system which serves the files of `fsys`, except the
+// ones ignored by `m`, as if they did not exist. Paths are matched against `m`
+// relative to the root of `fsys`, so a Repository from CompileRepositoryFS can
+// be used to honor every ignore file in the tree.
+//
+// Open, ReadDir, Stat and Glob all hide the ignored entries, which means that
+// http.FS, template.ParseFS and fs.WalkDir work on the filtered tree as is.
+func FilterFS(fsys fs.FS, m DirIgnoreParser) fs.FS {
+	return &filterFS{fsys: fsys, m: m}
+}
+
+// filterFS is the fs.FS returned by FilterFS.
+type filterFS struct {
+	fsys fs.FS
+	m    DirIgnoreParser
+}
+
+// hidden reports whether the entry `name`, which is a directory if `isDir` is
+// set, is ignored. The root is never ignored.
+func (f *filterFS) hidden(name string, isDir bool) bool {
+	return name != "." && f.m.MatchesPathIsDir(name, isDir)
+}
+
+// filter returns the `entries` of the directory `dir` which are not ignored.
+func (f *filterFS) filter(dir string, entries []fs.DirEntry) []fs.DirEntry {
+	kept := entries[:0]
+	for _, e := range entries {
+		if !f.hidden(path.Join(dir, e.Name()), e.IsDir()) {
+			kept = append(kept, e)
+		}
+	}
+	return kept
+}
+
+// Open implements fs.FS. Directories opened through it only list the entries
+// which are not ignored.
+func (f *filterFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	file, err := f.fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	if f.hidden(name, info.IsDir()) {
+		file.Close()
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	if dir, ok := file.(fs.ReadDirFile); ok && info.IsDir() {
+		return &filterDir{ReadDirFile: dir, fsys: f, name: name}, nil
+	}
+	return file, nil
+}
+
+// ReadDir implements fs.ReadDirFS.
+func (f *filterFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	entries, err := fs.ReadDir(f.fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	if f.hidden(name, true) {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+	return f.filter(name, entries), nil
+}
+
+// Stat implements fs.StatFS.
+func (f *filterFS) Stat(name string) (fs.FileInfo, error) {
+	info, err := fs.Stat(f.fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	if f.hidden(name, info.IsDir()) {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrNotExist}
+	}
+	return info, nil
+}
+
+// Glob implements fs.GlobFS.
+func (f *filterFS) Glob(pattern string) ([]string, error) {
+	matches, err := fs.Glob(f.fsys, pattern)
+	if err != nil {
+		return nil, err
+	}
+	kept := matches[:0]
+	for _, name := range matches {
+		if _, err := f.Stat(name); err == nil {
+			kept = append(kept, name)
+		}
+	}
+	return kept, nil
+}
+
+////////////////////////////////////////////////////////////
+
+// filterDir is a directory opened through a filterFS.
+type filterDir struct {
+	fs.ReadDirFile
+	fsys *filterFS
+	name string
+}
+
+// ReadDir implements fs.ReadDirFile. When `n` is positive, it keeps reading
+// until at least one entry which is not ignored is found, or the end of the
+// directory is reached.
+func (d *filterDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	for {
+		entries, err := d.ReadDirFile.ReadDir(n)
+		entries = d.fsys.filter(d.name, entries)
+		if n <= 0 || len(entries) > 0 || err != nil {
+			return entries, err
+		}
+	}
+}
+
+////////////////////////////////////////////////////////////
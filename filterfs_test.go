@@ -0,0 +1,88 @@
+// Implement tests for `FilterFS`
+package ignore
+
+import (
+	"errors"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+////////////////////////////////////////////////////////////
+
+func TestFilterFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"main.go":        {},
+		"debug.log":      {},
+		"bin":            {},
+		"build/out.bin":  {},
+		"build/keep.txt": {},
+		"src/lib.go":     {},
+		"src/bin/tool":   {},
+		"src/a.log":      {},
+	}
+	filtered := FilterFS(fsys, CompileIgnoreLines("*.log", "build/", "!build/keep.txt", "bin/"))
+
+	// fstest.TestFS checks that Open, ReadDir, Stat and Glob agree with
+	// each other, and that exactly the expected files are visible.
+	if err := fstest.TestFS(filtered, "main.go", "bin", "src/lib.go"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"debug.log", "build", "build/keep.txt", "src/bin/tool", "src/a.log"} {
+		_, err := filtered.Open(name)
+		assert.True(t, errors.Is(err, fs.ErrNotExist), "%s should not exist", name)
+		_, err = fs.Stat(filtered, name)
+		assert.True(t, errors.Is(err, fs.ErrNotExist), "%s should not exist", name)
+	}
+
+	entries, err := fs.ReadDir(filtered, "src")
+	assert.Nil(t, err, "err should be nil")
+	assert.Equal(t, 1, len(entries), "src should only list lib.go")
+	assert.Equal(t, "lib.go", entries[0].Name(), "src should only list lib.go")
+
+	matches, err := fs.Glob(filtered, "*/*")
+	assert.Nil(t, err, "err should be nil")
+	assert.Equal(t, []string{"src/lib.go"}, matches)
+}
+
+// Validate that nested ignore files are honored through a Repository
+func TestFilterFS_Repository(t *testing.T) {
+	fsys := fstest.MapFS{
+		".gitignore":      {Data: []byte("*.log\n")},
+		"web/.gitignore":  {Data: []byte("dist/\n!keep.log\n")},
+		"web/dist/app.js": {},
+		"web/keep.log":    {},
+		"web/debug.log":   {},
+		"web/src/app.js":  {},
+	}
+	repo, err := CompileRepositoryFS(fsys, ".")
+	assert.Nil(t, err, "err should be nil")
+
+	if err := fstest.TestFS(FilterFS(fsys, repo), ".gitignore", "web/.gitignore", "web/keep.log", "web/src/app.js"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Validate that reading a directory in small batches skips ignored entries
+func TestFilterFS_ReadDirBatches(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.tmp": {}, "b.tmp": {}, "c.tmp": {}, "d.go": {}, "e.tmp": {}, "f.go": {},
+	}
+	dir, err := FilterFS(fsys, CompileIgnoreLines("*.tmp")).Open(".")
+	assert.Nil(t, err, "err should be nil")
+	defer dir.Close()
+
+	var names []string
+	for {
+		entries, err := dir.(fs.ReadDirFile).ReadDir(1)
+		if err != nil {
+			break
+		}
+		assert.Equal(t, 1, len(entries), "each batch should have an entry")
+		names = append(names, entries[0].Name())
+	}
+	assert.Equal(t, []string{"d.go", "f.go"}, names)
+}
@@ -0,0 +1,102 @@
+package ignore
+
+import (
+	"fmt"
+	"io/fs"
+	"io/ioutil"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+////////////////////////////////////////////////////////////
+
+// GcloudIgnoreFile is the name of the ignore file of `gcloud`, which is a
+// ".gitignore" file with "#!include:" directives, see WithIncludes.
+const GcloudIgnoreFile = ".gcloudignore"
+
+// includeDirective starts the lines which splice another ignore file in their
+// place, such as "#!include:.gitignore".
+const includeDirective = "#!include:"
+
+// includer reads the files named by "#!include:" directives.
+type includer struct {
+	// fsys holds the files, the OS file system is used if it is nil.
+	fsys fs.FS
+
+	// active holds the keys of the files being compiled, from the outermost
+	// one, to detect include cycles.
+	active []string
+}
+
+// newIncluder returns an includer for the lines of the file `source`, read from
+// `fsys`, or the OS file system if it is nil.
+func newIncluder(fsys fs.FS, source string) *includer {
+	inc := &includer{fsys: fsys}
+	if source != "" {
+		inc.active = []string{inc.key(source)}
+	}
+	return inc
+}
+
+// resolve returns the path of the file `target` named by a directive of the
+// file `from`, relative to the directory of `from`.
+func (inc *includer) resolve(from, target string) string {
+	if inc.fsys != nil {
+		return path.Join(path.Dir(from), target)
+	}
+	target = filepath.FromSlash(target)
+	if filepath.IsAbs(target) {
+		return target
+	}
+	return filepath.Join(filepath.Dir(from), target)
+}
+
+// key returns a form of the path `name` which is the same for every path of
+// the same file.
+func (inc *includer) key(name string) string {
+	if inc.fsys != nil {
+		return path.Clean(name)
+	}
+	if abs, err := filepath.Abs(name); err == nil {
+		return abs
+	}
+	return filepath.Clean(name)
+}
+
+// read returns the contents of the file `name`.
+func (inc *includer) read(name string) ([]byte, error) {
+	if inc.fsys != nil {
+		return fs.ReadFile(inc.fsys, name)
+	}
+	return ioutil.ReadFile(name)
+}
+
+////////////////////////////////////////////////////////////
+
+// include appends the patterns of the file `target`, named by a directive of
+// the file `from`, to the GitIgnore. The patterns are attributed to the
+// included file.
+func (gi *GitIgnore) include(inc *includer, from, target string) error {
+	if target == "" {
+		return fmt.Errorf("%s directive without a path", strings.TrimSuffix(includeDirective, ":"))
+	}
+	name := inc.resolve(from, target)
+	key := inc.key(name)
+	for _, active := range inc.active {
+		if active == key {
+			return fmt.Errorf("include cycle: %s is already being included", name)
+		}
+	}
+
+	bs, err := inc.read(name)
+	if err != nil {
+		return err
+	}
+	inc.active = append(inc.active, key)
+	gi.compileLinesIn(inc, name, strings.Split(string(bs), "\n"))
+	inc.active = inc.active[:len(inc.active)-1]
+	return nil
+}
+
+////////////////////////////////////////////////////////////
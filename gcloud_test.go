@@ -0,0 +1,94 @@
+// Implement tests for the "#!include:" directives of `WithIncludes`
+package ignore
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+////////////////////////////////////////////////////////////
+
+// Validate that included files are spliced in place, relative to the file
+// containing the directive, and that their patterns are attributed to them.
+func TestIncludes_Splice(t *testing.T) {
+	root := writeTestTree(t, map[string]string{
+		GcloudIgnoreFile:    "#!include:.gitignore\n!keep.log\n#!include: conf/extra.ignore\n",
+		".gitignore":        "# build output\n*.log\n*.tmp\n",
+		"conf/extra.ignore": "#!include:../shared.ignore\n!important.tmp\n",
+		"shared.ignore":     "*.bak\n",
+	})
+	defer os.RemoveAll(root)
+	fpath := filepath.Join(root, GcloudIgnoreFile)
+
+	object, err := Compile(WithFile(fpath), WithIncludes(true))
+	assert.Nil(t, err, "err should be nil")
+	assert.Equal(t, 0, len(object.Warnings()), "there should be no invalid lines")
+
+	assert.Equal(t, true, object.MatchesPath("debug.log"), "debug.log should match")
+	assert.Equal(t, false, object.MatchesPath("keep.log"), "keep.log should be re-included by a later line")
+	assert.Equal(t, true, object.MatchesPath("a/b.bak"), "a/b.bak should match")
+	assert.Equal(t, false, object.MatchesPath("important.tmp"), "important.tmp should be re-included")
+
+	_, how := object.MatchesPathHow("debug.log")
+	assert.Equal(t, filepath.Join(root, ".gitignore"), how.Source, "the pattern should be attributed to .gitignore")
+	assert.Equal(t, 2, how.LineNo, "the pattern should have its line in .gitignore")
+	result := object.Match("keep.log", false)
+	assert.Equal(t, fpath, result.Pattern.Source, "the pattern should be attributed to .gcloudignore")
+	assert.Equal(t, 2, result.Pattern.LineNo, "the pattern should have its line in .gcloudignore")
+	_, how = object.MatchesPathHow("a.bak")
+	assert.Equal(t, filepath.Join(root, "shared.ignore"), how.Source, "nested includes should be resolved")
+
+	// Without WithIncludes, the directives are comments
+	object, err = Compile(WithFile(fpath))
+	assert.Nil(t, err, "err should be nil")
+	assert.Equal(t, false, object.MatchesPath("debug.log"), "debug.log should not match")
+}
+
+func TestIncludes_Errors(t *testing.T) {
+	root := writeTestTree(t, map[string]string{
+		"a.ignore": "*.a\n#!include:b.ignore\n",
+		"b.ignore": "*.b\n#!include:./a.ignore\n#!include:missing.ignore\n#!include:\n",
+	})
+	defer os.RemoveAll(root)
+
+	object, err := Compile(WithFile(filepath.Join(root, "a.ignore")), WithIncludes(true))
+	assert.Nil(t, err, "err should be nil")
+	assert.Equal(t, true, object.MatchesPath("x.a"), "x.a should match")
+	assert.Equal(t, true, object.MatchesPath("x.b"), "x.b should match")
+
+	warnings := object.Warnings()
+	assert.Equal(t, 3, len(warnings), "there should be 3 invalid directives")
+	for i, warning := range warnings {
+		assert.Equal(t, filepath.Join(root, "b.ignore"), warning.Source, "the directive should be attributed to b.ignore")
+		assert.Equal(t, i+2, warning.LineNo)
+	}
+	assert.Contains(t, warnings[0].Error(), "include cycle")
+
+	_, err = Compile(WithFile(filepath.Join(root, "a.ignore")), WithIncludes(true), WithStrict(true))
+	assert.NotNil(t, err, "err should be an include cycle")
+
+	_, err = Compile(WithLines("*.o"), WithDialect(DialectDocker), WithIncludes(true))
+	assert.NotNil(t, err, "err should be an unsupported dialect")
+}
+
+func TestIncludes_FS(t *testing.T) {
+	fsys := mapFS(map[string]string{
+		"app/" + GcloudIgnoreFile: "#!include:.gitignore\n#!include:../.gitignore\n",
+		"app/.gitignore":          "/dist/\n",
+		".gitignore":              "node_modules/\n",
+	})
+
+	object, err := Compile(WithFS(fsys, "app/"+GcloudIgnoreFile), WithIncludes(true))
+	assert.Nil(t, err, "err should be nil")
+	assert.Equal(t, 0, len(object.Warnings()), "there should be no invalid lines")
+	assert.Equal(t, true, object.MatchesPath("dist/"), "dist/ should match")
+	assert.Equal(t, true, object.MatchesPath("lib/node_modules/"), "lib/node_modules/ should match")
+
+	_, how := object.MatchesPathHow("dist/")
+	assert.Equal(t, "app/.gitignore", how.Source, "the pattern should be attributed to app/.gitignore")
+}
+
+////////////////////////////////////////////////////////////
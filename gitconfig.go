@@ -0,0 +1,146 @@
+package ignore
+
+import (
+	"bufio"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+////////////////////////////////////////////////////////////
+
+// gitConfigValue returns the last value of `key` in the `section` of the git
+// config file at `fpath`. Section and key names are case insensitive, and
+// sections with a subsection, such as `[remote "origin"]`, are not looked at.
+// A missing file is the same as a file without the key.
+func gitConfigValue(fpath, section, key string) (string, bool, error) {
+	f, err := os.Open(fpath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", false, nil
+	} else if err != nil {
+		return "", false, err
+	}
+	defer f.Close()
+
+	var (
+		value   string
+		found   bool
+		current string
+	)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		for strings.HasPrefix(line, "[") {
+			end := strings.IndexByte(line, ']')
+			if end < 0 {
+				break
+			}
+			current = strings.ToLower(strings.TrimSpace(line[1:end]))
+			line = strings.TrimSpace(line[end+1:])
+		}
+		if line == "" || line[0] == '#' || line[0] == ';' || current != section {
+			continue
+		}
+
+		name, raw := line, ""
+		if eq := strings.IndexByte(line, '='); eq >= 0 {
+			name, raw = line[:eq], line[eq+1:]
+		} else {
+			// A key without a value is a boolean set to true.
+			raw = "true"
+		}
+		if !strings.EqualFold(strings.TrimSpace(name), key) {
+			continue
+		}
+
+		// A value ending with a backslash continues on the next line.
+		for strings.HasSuffix(raw, `\`) && !strings.HasSuffix(raw, `\\`) && scanner.Scan() {
+			raw = raw[:len(raw)-1] + scanner.Text()
+		}
+		value, found = parseGitConfigValue(raw), true
+	}
+	if err := scanner.Err(); err != nil {
+		return "", false, err
+	}
+	return value, found, nil
+}
+
+// parseGitConfigValue unquotes the raw value of a git config variable. The
+// whitespace around it and comments are dropped, except inside of quotes.
+func parseGitConfigValue(raw string) string {
+	var (
+		sb     strings.Builder
+		quoted bool
+		spaces int // Pending whitespace, only kept if more text follows
+	)
+	raw = strings.TrimLeft(raw, " \t")
+	for i := 0; i < len(raw); i++ {
+		c := raw[i]
+		switch {
+		case c == '"':
+			quoted = !quoted
+			continue
+		case !quoted && (c == '#' || c == ';'):
+			return sb.String()
+		case !quoted && (c == ' ' || c == '\t'):
+			spaces++
+			continue
+		}
+
+		sb.WriteString(strings.Repeat(" ", spaces))
+		spaces = 0
+		if c == '\\' && i+1 < len(raw) {
+			i++
+			switch raw[i] {
+			case 'n':
+				c = '\n'
+			case 't':
+				c = '\t'
+			case 'b':
+				c = '\b'
+			default:
+				c = raw[i]
+			}
+		}
+		sb.WriteByte(c)
+	}
+	return sb.String()
+}
+
+// parseGitBool parses a boolean git config value, and reports whether it is
+// a valid one.
+func parseGitBool(value string) (bool, bool) {
+	switch strings.ToLower(value) {
+	case "true", "yes", "on", "1":
+		return true, true
+	case "false", "no", "off", "0", "":
+		return false, true
+	}
+	return false, false
+}
+
+// expandHome replaces a leading "~/" in the path `p` with the home directory
+// of the user, like git does for path values.
+func expandHome(p string) string {
+	if p != "~" && !strings.HasPrefix(p, "~/") {
+		return p
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return p
+	}
+	return filepath.Join(home, filepath.FromSlash(p[1:]))
+}
+
+// xdgConfigPath returns the path of the file `name` in git's directory under
+// $XDG_CONFIG_HOME, which defaults to "~/.config".
+func xdgConfigPath(name string) string {
+	if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" {
+		return filepath.Join(xdg, "git", name)
+	}
+	return expandHome("~/.config/git/" + name)
+}
+
+////////////////////////////////////////////////////////////
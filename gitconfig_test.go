@@ -0,0 +1,66 @@
+// Implement tests for reading git config files
+package ignore
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+////////////////////////////////////////////////////////////
+
+func TestGitConfigValue(t *testing.T) {
+	root := writeTestTree(t, map[string]string{
+		"config": `# a comment
+[user]
+	name = Someone
+[core]
+	bare = false
+	excludesFile = /first
+[remote "origin"]
+	excludesFile = /remote
+[Core] ExcludesFile = "~/with \"quotes\"" ; trailing comment
+[core]
+	ignorecase
+	long = a \
+b
+`,
+	})
+	defer os.RemoveAll(root)
+	fpath := filepath.Join(root, "config")
+
+	value, ok, err := gitConfigValue(fpath, "core", "excludesfile")
+	assert.Nil(t, err, "err should be nil")
+	assert.Equal(t, true, ok, "core.excludesFile should be found")
+	assert.Equal(t, `~/with "quotes"`, value, "the last value should win")
+
+	value, ok, _ = gitConfigValue(fpath, "core", "ignorecase")
+	assert.Equal(t, true, ok, "core.ignorecase should be found")
+	assert.Equal(t, "true", value, "a key without a value should be true")
+
+	value, _, _ = gitConfigValue(fpath, "core", "long")
+	assert.Equal(t, "a b", value, "continued lines should be joined")
+
+	_, ok, _ = gitConfigValue(fpath, "core", "missing")
+	assert.Equal(t, false, ok, "core.missing should not be found")
+
+	_, ok, err = gitConfigValue(filepath.Join(root, "missing"), "core", "excludesfile")
+	assert.Nil(t, err, "a missing file should not be an error")
+	assert.Equal(t, false, ok, "nothing should be found in a missing file")
+}
+
+func TestParseGitConfigValue(t *testing.T) {
+	cases := map[string]string{
+		` plain `:             "plain",
+		`two  words # note`:   "two  words",
+		`"  kept  " ; note`:   "  kept  ",
+		`a\tb\\c`:             "a\tb\\c",
+		`"semi;colon"`:        "semi;colon",
+		`mixed" quoted "part`: "mixed quoted part",
+	}
+	for raw, expected := range cases {
+		assert.Equal(t, expected, parseGitConfigValue(raw), "raw value %q", raw)
+	}
+}
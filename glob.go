@@ -0,0 +1,259 @@
+package ignore
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+////////////////////////////////////////////////////////////
+
+var (
+	errTrailingBackslash   = errors.New("pattern ends with an unescaped backslash")
+	errUnterminatedBracket = errors.New("unterminated bracket expression")
+)
+
+// posixClasses maps the names of the character classes which can be used in
+// a bracket expression, as in "[[:digit:]]", to their (ASCII) members.
+var posixClasses = map[string]func(c byte) bool{
+	"alnum":  func(c byte) bool { return isAlpha(c) || isDigit(c) },
+	"alpha":  isAlpha,
+	"blank":  func(c byte) bool { return c == ' ' || c == '\t' },
+	"cntrl":  func(c byte) bool { return c < ' ' || c == 0x7f },
+	"digit":  isDigit,
+	"graph":  func(c byte) bool { return c > ' ' && c < 0x7f },
+	"lower":  isLower,
+	"print":  func(c byte) bool { return c >= ' ' && c < 0x7f },
+	"punct":  func(c byte) bool { return c > ' ' && c < 0x7f && !isAlpha(c) && !isDigit(c) },
+	"space":  func(c byte) bool { return c == ' ' || (c >= '\t' && c <= '\r') },
+	"upper":  isUpper,
+	"xdigit": func(c byte) bool { return isDigit(c) || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F') },
+}
+
+func isDigit(c byte) bool { return c >= '0' && c <= '9' }
+func isLower(c byte) bool { return c >= 'a' && c <= 'z' }
+func isUpper(c byte) bool { return c >= 'A' && c <= 'Z' }
+func isAlpha(c byte) bool { return isLower(c) || isUpper(c) }
+
+////////////////////////////////////////////////////////////
+
+// charClass is a parsed bracket expression, such as "[a-z]" or "[!._]".
+type charClass struct {
+	negated bool
+	ascii   [utf8.RuneSelf]bool
+	runes   [][2]rune // ranges of non-ASCII members, inclusive
+}
+
+// addRange adds the members `lo` to `hi` (inclusive) to the class.
+func (cc *charClass) addRange(lo, hi rune) {
+	for ; lo <= hi && lo < utf8.RuneSelf; lo++ {
+		cc.ascii[lo] = true
+	}
+	if lo <= hi {
+		cc.runes = append(cc.runes, [2]rune{lo, hi})
+	}
+}
+
+// parseBracket parses the bracket expression which starts at `glob[i]`, and
+// returns it along with the index just past its closing "]". The syntax is
+// the one of git's wildmatch: a leading "!" or "^" negates the expression, a
+// "]" right after the opening bracket (or the negation) is a member, ranges
+// are written as "a-z", "\" escapes the next character, and POSIX character
+// classes are written as "[:alpha:]".
+func parseBracket(glob string, i int) (*charClass, int, error) {
+	cc := &charClass{}
+	i++
+	if i < len(glob) && (glob[i] == '!' || glob[i] == '^') {
+		cc.negated = true
+		i++
+	}
+
+	prev := rune(-1)
+	for first := true; ; first = false {
+		if i >= len(glob) {
+			return nil, 0, errUnterminatedBracket
+		}
+		if glob[i] == ']' && !first {
+			return cc, i + 1, nil
+		}
+
+		switch {
+		case glob[i] == '\\':
+			if i+1 >= len(glob) {
+				return nil, 0, errUnterminatedBracket
+			}
+			r, n := utf8.DecodeRuneInString(glob[i+1:])
+			cc.addRange(r, r)
+			prev, i = r, i+1+n
+
+		case glob[i] == '-' && prev >= 0 && i+1 < len(glob) && glob[i+1] != ']':
+			i++
+			if glob[i] == '\\' {
+				if i+1 >= len(glob) {
+					return nil, 0, errUnterminatedBracket
+				}
+				i++
+			}
+			hi, n := utf8.DecodeRuneInString(glob[i:])
+			cc.addRange(prev, hi)
+			prev, i = -1, i+n
+
+		case glob[i] == '[' && i+1 < len(glob) && glob[i+1] == ':':
+			end := strings.IndexByte(glob[i+2:], ']')
+			if end < 0 {
+				return nil, 0, errUnterminatedBracket
+			}
+			name := glob[i+2 : i+2+end]
+			if !strings.HasSuffix(name, ":") {
+				// Not a character class, the "[" is an ordinary member.
+				cc.addRange('[', '[')
+				prev, i = '[', i+1
+				continue
+			}
+			name = strings.TrimSuffix(name, ":")
+			isMember, ok := posixClasses[name]
+			if !ok {
+				return nil, 0, fmt.Errorf("unknown character class %q", name)
+			}
+			for c := 0; c < utf8.RuneSelf; c++ {
+				if isMember(byte(c)) {
+					cc.ascii[c] = true
+				}
+			}
+			prev, i = -1, i+2+end+1
+
+		default:
+			r, n := utf8.DecodeRuneInString(glob[i:])
+			cc.addRange(r, r)
+			prev, i = r, i+n
+		}
+	}
+}
+
+// regexp returns a regular expression matching one character in the class.
+// A "/" is never matched, even if it is listed in the class.
+func (cc *charClass) regexp() string {
+	ascii := cc.ascii
+	ascii['/'] = cc.negated
+
+	var sb strings.Builder
+	for lo := 0; lo < len(ascii); lo++ {
+		if !ascii[lo] {
+			continue
+		}
+		hi := lo
+		for hi+1 < len(ascii) && ascii[hi+1] {
+			hi++
+		}
+		fmt.Fprintf(&sb, `\x%02x`, lo)
+		if hi > lo {
+			fmt.Fprintf(&sb, `-\x%02x`, hi)
+		}
+		lo = hi
+	}
+	for _, r := range cc.runes {
+		fmt.Fprintf(&sb, `\x{%x}-\x{%x}`, r[0], r[1])
+	}
+
+	if cc.negated {
+		return "[^" + sb.String() + "]"
+	}
+	if sb.Len() == 0 {
+		// An empty class, which can only come from "[/]".
+		return `[^\x00-\x{10ffff}]`
+	}
+	return "[" + sb.String() + "]"
+}
+
+////////////////////////////////////////////////////////////
+
+// validateGlob returns the error globToRegexp would return for `glob`, if any,
+// without building the regular expression: a trailing backslash or an
+// unterminated bracket expression.
+func validateGlob(glob string) error {
+	for i := 0; i < len(glob); {
+		switch glob[i] {
+		case '\\':
+			if i+1 >= len(glob) {
+				return errTrailingBackslash
+			}
+			_, n := utf8.DecodeRuneInString(glob[i+1:])
+			i += 1 + n
+
+		case '[':
+			_, j, err := parseBracket(glob, i)
+			if err != nil {
+				return err
+			}
+			i = j
+
+		default:
+			i++
+		}
+	}
+	return nil
+}
+
+// globToRegexp translates the shell glob `glob` into the body of a regular
+// expression, following the rules of git's wildmatch with the FNM_PATHNAME
+// flag: "*" and "?" never match a "/", "**" matches across directories when
+// it makes up a whole path component [Rule 9], and brackets are character
+// classes.
+func globToRegexp(glob string) (string, error) {
+	var sb strings.Builder
+	for i := 0; i < len(glob); {
+		switch c := glob[i]; c {
+		case '\\':
+			if i+1 >= len(glob) {
+				return "", errTrailingBackslash
+			}
+			_, n := utf8.DecodeRuneInString(glob[i+1:])
+			sb.WriteString(regexp.QuoteMeta(glob[i+1 : i+1+n]))
+			i += 1 + n
+
+		case '?':
+			sb.WriteString(`[^/]`)
+			i++
+
+		case '*':
+			j := i
+			for j < len(glob) && glob[j] == '*' {
+				j++
+			}
+			atStart := i == 0 || glob[i-1] == '/'
+			switch {
+			case j-i < 2 || !atStart:
+				sb.WriteString(`[^/]*`)
+			case j == len(glob):
+				// [Rule 9.ii] a trailing "/**" matches everything inside.
+				sb.WriteString(`.*`)
+			case glob[j] == '/':
+				// [Rule 9.i, 9.iii] "**/" matches zero or more directories.
+				sb.WriteString(`(.*/)?`)
+				j++
+			default:
+				// [Rule 9.iv] other consecutive asterisks are a plain "*".
+				sb.WriteString(`[^/]*`)
+			}
+			i = j
+
+		case '[':
+			cc, j, err := parseBracket(glob, i)
+			if err != nil {
+				return "", err
+			}
+			sb.WriteString(cc.regexp())
+			i = j
+
+		default:
+			_, n := utf8.DecodeRuneInString(glob[i:])
+			sb.WriteString(regexp.QuoteMeta(glob[i : i+n]))
+			i += n
+		}
+	}
+	return sb.String(), nil
+}
+
+////////////////////////////////////////////////////////////
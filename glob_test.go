@@ -0,0 +1,162 @@
+// Implement tests for the glob translation, ported from git's t/t3070-wildmatch.sh
+package ignore
+
+import (
+	"regexp"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+////////////////////////////////////////////////////////////
+
+// Helper function which reports whether `glob` matches all of `text`. An
+// invalid glob never matches anything, the same as in git.
+func globMatches(glob, text string) bool {
+	body, err := globToRegexp(glob)
+	if err != nil {
+		return false
+	}
+	return regexp.MustCompile("^" + body + "$").MatchString(text)
+}
+
+////////////////////////////////////////////////////////////
+
+func TestGlobToRegexp_Brackets(t *testing.T) {
+	cases := []struct {
+		text, glob string
+		match      bool
+	}{
+		{"ten", "[ten]", false},
+		{"ten", "**[!te]", true},
+		{"ten", "**[!ten]", false},
+		{"ten", "t[a-g]n", true},
+		{"ten", "t[!a-g]n", false},
+		{"ton", "t[!a-g]n", true},
+		{"ton", "t[^a-g]n", true},
+		{"a]b", "a[]]b", true},
+		{"a-b", "a[]-]b", true},
+		{"a]b", "a[]-]b", true},
+		{"aab", "a[]-]b", false},
+		{"aab", "a[]a-]b", true},
+		{"]", "]", true},
+		{"1", "[[:digit:][:upper:][:space:]]", true},
+		{"a", "[[:digit:][:upper:][:space:]]", false},
+		{"A", "[[:digit:][:upper:][:space:]]", true},
+		{" ", "[[:digit:][:upper:][:space:]]", true},
+		{".", "[[:digit:][:upper:][:space:]]", false},
+		{".", "[[:digit:][:punct:][:space:]]", true},
+		{"5", "[[:xdigit:]]", true},
+		{"f", "[[:xdigit:]]", true},
+		{"D", "[[:xdigit:]]", true},
+		{"_", "[[:alnum:][:alpha:][:blank:][:cntrl:][:digit:][:graph:][:lower:][:print:][:punct:][:space:][:upper:][:xdigit:]]", true},
+		{".", "[^[:alnum:][:alpha:][:blank:][:cntrl:][:digit:][:lower:][:space:][:upper:][:xdigit:]]", true},
+		{"5", "[a-c[:digit:]x-z]", true},
+		{"b", "[a-c[:digit:]x-z]", true},
+		{"y", "[a-c[:digit:]x-z]", true},
+		{"q", "[a-c[:digit:]x-z]", false},
+		{"]", `[\\-^]`, true},
+		{"[", `[\\-^]`, false},
+		{"-", `[\-_]`, true},
+		{"]", `[\]]`, true},
+		{`\]`, `[\]]`, false},
+		{`\`, `[\]]`, false},
+		{"ab", "a[]b", false},
+		{"a[]b", "a[]b", false},
+		{"ab[", "ab[", false},
+		{"ab", "[!", false},
+		{"ab", "[-", false},
+		{"-", "[-]", true},
+		{"-", "[a-", false},
+		{"-", "[!a-", false},
+		{"-", "[--A]", true},
+		{"5", "[--A]", true},
+		{" ", "[ --]", true},
+		{"$", "[ --]", true},
+		{"-", "[ --]", true},
+		{"0", "[ --]", false},
+		{"-", "[---]", true},
+		{"-", "[------]", true},
+		{"j", "[a-e-n]", false},
+		{"-", "[a-e-n]", true},
+		{"a", "[!------]", true},
+		{"[", "[]-a]", false},
+		{"^", "[]-a]", true},
+		{"^", "[!]-a]", false},
+		{"[", "[!]-a]", true},
+		{"^", "[a^bc]", true},
+		{"-b]", "[a-]b]", true},
+		{`\`, `[\]`, false},
+		{`\`, `[\\]`, true},
+		{`\`, `[!\\]`, false},
+		{"G", `[A-\\]`, true},
+		{",", "[,]", true},
+		{",", `[\\,]`, true},
+		{`\`, `[\\,]`, true},
+		{"-", "[,-.]", true},
+		{"+", "[,-.]", false},
+		{"-.]", "[,-.]", false},
+		{"2", `[\1-\3]`, true},
+		{"3", `[\1-\3]`, true},
+		{"4", `[\1-\3]`, false},
+		{`\`, `[[-\]]`, true},
+		{"[", `[[-\]]`, true},
+		{"]", `[[-\]]`, true},
+		{"-", `[[-\]]`, false},
+		{"a", "[[:alpha:]", false},
+		{"a", "[[:nope:]]", false},
+		{"[", "[[:alpha]", true},
+		{"a/b", "a[/]b", false},
+		{"a/b", "a[!x]b", false},
+		{"é", "[é]", true},
+		{"é", "[!a]", true},
+	}
+
+	for _, c := range cases {
+		if runtime.GOOS == "windows" && strings.Contains(c.text, `\`) {
+			continue
+		}
+		assert.Equal(t, c.match, globMatches(c.glob, c.text), "glob %q against %q", c.glob, c.text)
+	}
+}
+
+func TestGlobToRegexp_Errors(t *testing.T) {
+	for _, glob := range []string{`foo\`, "a[b", "[!", "[[:nope:]]", `[a\`} {
+		_, err := globToRegexp(glob)
+		assert.NotNil(t, err, "%q should not be a valid glob", glob)
+		assert.Equal(t, err, validateGlob(glob), "%q should be validated like it is translated", glob)
+	}
+	for _, glob := range []string{`foo\\`, "a[]b]", `[a\]]`, "**/[[:digit:]]*"} {
+		assert.Nil(t, validateGlob(glob), "%q should be a valid glob", glob)
+	}
+}
+
+// Validate bracket expressions from the github/gitignore templates
+func TestCompileIgnoreLines_BracketExpressions(t *testing.T) {
+	gitIgnore := []string{"*.[oa]", "[Bb]uild/", "/[!._]*.tmp", "[[:digit:]]*.log", "ab[c"}
+	object := CompileIgnoreLines(gitIgnore...)
+
+	assert.Equal(t, 4, len(object.patterns), "the unterminated bracket should not be compiled")
+
+	assert.Equal(t, true, object.MatchesPath("main.o"), "main.o should match")
+	assert.Equal(t, true, object.MatchesPath("lib/libfoo.a"), "lib/libfoo.a should match")
+	assert.Equal(t, false, object.MatchesPath("main.c"), "main.c should not match")
+
+	assert.Equal(t, true, object.MatchesPath("Build/"), "Build/ should match")
+	assert.Equal(t, true, object.MatchesPath("sub/build/out"), "sub/build/out should match")
+	assert.Equal(t, false, object.MatchesPath("BUILD/"), "BUILD/ should not match")
+	assert.Equal(t, false, object.MatchesPath("build"), "build should not match as a file")
+
+	assert.Equal(t, true, object.MatchesPath("x.tmp"), "x.tmp should match")
+	assert.Equal(t, false, object.MatchesPath(".x.tmp"), ".x.tmp should not match")
+	assert.Equal(t, false, object.MatchesPath("_x.tmp"), "_x.tmp should not match")
+	assert.Equal(t, false, object.MatchesPath("sub/x.tmp"), "sub/x.tmp should not match")
+
+	assert.Equal(t, true, object.MatchesPath("2021-01-01.log"), "2021-01-01.log should match")
+	assert.Equal(t, false, object.MatchesPath("today.log"), "today.log should not match")
+
+	assert.Equal(t, false, object.MatchesPath("abc"), "abc should not match")
+	assert.Equal(t, false, object.MatchesPath("ab[c"), "ab[c should not match")
+}
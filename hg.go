@@ -0,0 +1,185 @@
+package ignore
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+////////////////////////////////////////////////////////////
+
+// HgIgnoreFile is the name of the ignore file at the root of a Mercurial
+// repository.
+const HgIgnoreFile = ".hgignore"
+
+// The kinds of patterns of a ".hgignore" file, named after the prefix which
+// selects them on a single line.
+const (
+	hgRegexp   = "relre:"
+	hgGlob     = "relglob:"
+	hgRootGlob = "rootglob:"
+)
+
+// hgSyntaxes maps the names accepted by "syntax:" lines and as a prefix of a
+// pattern to the kind of pattern they select, in the order Mercurial tries
+// them.
+var hgSyntaxes = []struct{ name, kind string }{
+	{"re", hgRegexp},
+	{"regexp", hgRegexp},
+	{"glob", hgGlob},
+	{"rootglob", hgRootGlob},
+}
+
+// hgCommentRe matches a comment, which starts at the first "#" not escaped
+// with a backslash. The first group is the part of the line to keep.
+var hgCommentRe = regexp.MustCompile(`((?:^|[^\\])(?:\\\\)*)#.*`)
+
+// hgParser parses the lines of a ".hgignore" file. It is stateful, since a
+// "syntax:" line changes the kind of the patterns which follow it.
+type hgParser struct {
+	syntax string
+}
+
+// newHgParser returns a hgParser for a new file, whose patterns are regular
+// expressions until a "syntax:" line says otherwise.
+func newHgParser() *hgParser {
+	return &hgParser{syntax: hgRegexp}
+}
+
+// parseLine parses a line of a ".hgignore" file. The patterns are matched
+// against the whole path relative to the root of the repository, and ignore
+// the contents of the directories they match. Mercurial has no negation.
+func (p *hgParser) parseLine(line string) (*IgnorePattern, error) {
+	if strings.Contains(line, "#") {
+		if m := hgCommentRe.FindStringSubmatchIndex(line); m != nil {
+			line = line[:m[3]]
+		}
+		line = strings.Replace(line, `\#`, "#", -1)
+	}
+	line = strings.TrimRight(line, " \t\r\n\v\f")
+	if line == "" {
+		return nil, nil
+	}
+
+	if strings.HasPrefix(line, "syntax:") {
+		name := strings.TrimSpace(line[len("syntax:"):])
+		for _, s := range hgSyntaxes {
+			if s.name == name {
+				p.syntax = s.kind
+				return nil, nil
+			}
+		}
+		return nil, fmt.Errorf("invalid syntax %q", name)
+	}
+
+	for _, unsupported := range []string{"include:", "subinclude:"} {
+		if strings.HasPrefix(line, unsupported) {
+			return nil, fmt.Errorf("%q patterns are not supported", strings.TrimSuffix(unsupported, ":"))
+		}
+	}
+	kind, pattern := p.syntax, line
+	for _, s := range hgSyntaxes {
+		if strings.HasPrefix(line, s.kind) {
+			kind, pattern = s.kind, line[len(s.kind):]
+			break
+		} else if strings.HasPrefix(line, s.name+":") {
+			kind, pattern = s.kind, line[len(s.name)+1:]
+			break
+		}
+	}
+
+	var expr string
+	switch kind {
+	case hgRegexp:
+		// The regexp may match anywhere in the path, unless it starts with
+		// a caret.
+		expr = pattern
+		if !strings.HasPrefix(pattern, "^") {
+			expr = ".*" + pattern
+		}
+	case hgGlob:
+		expr = "(?:|.*/)" + hgGlobToRegexp(pattern) + "(?:/|$)"
+	case hgRootGlob:
+		expr = hgGlobToRegexp(pattern) + "(?:/|$)"
+	}
+
+	// Mercurial only anchors the regexps at the start of the path.
+	re, err := regexp.Compile("^(?:" + expr + ")")
+	if err != nil {
+		return nil, err
+	}
+	return &IgnorePattern{
+		glob:     kind + pattern,
+		anchored: true,
+		re:       re,
+	}, nil
+}
+
+// hgGlobToRegexp converts a Mercurial glob into a regexp. Unlike git, "?"
+// also matches a slash, and "{a,b}" matches either "a" or "b".
+func hgGlobToRegexp(glob string) string {
+	var sb strings.Builder
+	groups := 0
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch {
+		case c == '*':
+			if i+1 < len(glob) && glob[i+1] == '*' {
+				i++
+				if i+1 < len(glob) && glob[i+1] == '/' {
+					i++
+					sb.WriteString("(?:.*/)?")
+				} else {
+					sb.WriteString(".*")
+				}
+			} else {
+				sb.WriteString("[^/]*")
+			}
+
+		case c == '?':
+			sb.WriteString(".")
+
+		case c == '[':
+			j := i + 1
+			if j < len(glob) && (glob[j] == '!' || glob[j] == ']') {
+				j++
+			}
+			for j < len(glob) && glob[j] != ']' {
+				j++
+			}
+			if j >= len(glob) {
+				sb.WriteString(`\[`)
+				continue
+			}
+			class := strings.Replace(glob[i+1:j], `\`, `\\`, -1)
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			} else if strings.HasPrefix(class, "^") {
+				class = `\` + class
+			}
+			sb.WriteString("[" + class + "]")
+			i = j
+
+		case c == '{':
+			groups++
+			sb.WriteString("(?:")
+
+		case c == '}' && groups > 0:
+			groups--
+			sb.WriteString(")")
+
+		case c == ',' && groups > 0:
+			sb.WriteString("|")
+
+		case c == '\\' && i+1 < len(glob):
+			i++
+			sb.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+
+		default:
+			sb.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		}
+	}
+	return sb.String()
+}
+
+////////////////////////////////////////////////////////////
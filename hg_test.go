@@ -0,0 +1,112 @@
+// Implement tests for the .hgignore dialect
+package ignore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+////////////////////////////////////////////////////////////
+
+// Helper function which compiles `lines` as a ".hgignore" file.
+func compileHg(t *testing.T, lines ...string) *GitIgnore {
+	object, err := Compile(WithDialect(DialectMercurial), WithLines(lines...))
+	assert.Nil(t, err, "err should be nil")
+	return object
+}
+
+////////////////////////////////////////////////////////////
+
+func TestHg_Syntaxes(t *testing.T) {
+	cases := []struct {
+		lines []string
+		path  string
+		match bool
+	}{
+		// Regexps are the default, and match anywhere in the path
+		{[]string{`\.pyc$`}, "a/b.pyc", true},
+		{[]string{`\.pyc$`}, "a/b.pyc.txt", false},
+		{[]string{`build`}, "src/rebuild.go", true},
+		{[]string{`^build/`}, "build/out", true},
+		{[]string{`^build/`}, "src/build/out", false},
+		{[]string{`^build$`}, "build/out", true},
+		{[]string{`a|^b`}, "xa", true},
+		{[]string{`a|^b`}, "xb", false},
+
+		// Globs match at any depth, rootglobs from the root
+		{[]string{"syntax: glob", "*.o"}, "a/b/c.o", true},
+		{[]string{"syntax: glob", "*.o"}, "c.o/d", true},
+		{[]string{"syntax: glob", "*.o"}, "a/c.of", false},
+		{[]string{"syntax: glob", "build/*.o"}, "src/build/a.o", true},
+		{[]string{"syntax: glob", "build/*.o"}, "src/build/x/a.o", false},
+		{[]string{"syntax: rootglob", "build/*.o"}, "build/a.o", true},
+		{[]string{"syntax: rootglob", "build/*.o"}, "src/build/a.o", false},
+		{[]string{"syntax: rootglob", "*.o"}, "a/c.o", false},
+		{[]string{"syntax: glob", "a?c"}, "a/c", true},
+		{[]string{"syntax: glob", "**/tmp"}, "tmp", true},
+		{[]string{"syntax: glob", "src/**/tmp"}, "src/a/b/tmp", true},
+		{[]string{"syntax: glob", "*.{jpg,png}"}, "img/a.png", true},
+		{[]string{"syntax: glob", "*.{jpg,png}"}, "img/a.gif", false},
+		{[]string{"syntax: glob", "[!a]b"}, "cb", true},
+		{[]string{"syntax: glob", "[!a]b"}, "ab", false},
+		{[]string{"syntax: glob", `\*.txt`}, "*.txt", true},
+		{[]string{"syntax: glob", `\*.txt`}, "a.txt", false},
+		{[]string{"syntax: glob", "a.b"}, "axb", false},
+
+		// Prefixes override the syntax of a single line
+		{[]string{"syntax: glob", `re:\.o$`}, "a.o", true},
+		{[]string{"syntax: glob", `regexp:^a`}, "abc", true},
+		{[]string{`glob:*.o`, `^x`}, "a/b.o", true},
+		{[]string{`glob:*.o`, `^x`}, "xyz", true},
+		{[]string{`rootglob:*.o`}, "a/b.o", false},
+		{[]string{`relglob:*.o`}, "a/b.o", true},
+		{[]string{"syntax: glob", "syntax: regexp", `^a.c`}, "abc", true},
+
+		// Comments
+		{[]string{`^a # comment`}, "a", true},
+		{[]string{`^a\#b`}, "a#b", true},
+		{[]string{`# ^a`}, "a", false},
+	}
+	for _, c := range cases {
+		object := compileHg(t, c.lines...)
+		assert.Equal(t, c.match, object.MatchesPath(c.path), "%q should match %q: %v", c.lines, c.path, c.match)
+	}
+}
+
+func TestHg_Warnings(t *testing.T) {
+	object := compileHg(t, "syntax: bogus", "include:other.hgignore", "(unclosed", "syntax: glob", "*.o")
+	warnings := object.Warnings()
+	assert.Equal(t, 3, len(warnings), "there should be 3 invalid lines")
+	assert.Equal(t, []int{1, 2, 3}, []int{warnings[0].LineNo, warnings[1].LineNo, warnings[2].LineNo})
+	assert.Equal(t, true, object.MatchesPath("a.o"), "a.o should match")
+
+	_, err := Compile(WithDialect(DialectMercurial), WithLines("*.o"), WithStrict(true))
+	assert.NotNil(t, err, "err should be an invalid regexp")
+	assert.Equal(t, "mercurial", DialectMercurial.String())
+}
+
+// Validate that each input starts with the regexp syntax, and that the patterns
+// are attributed like the ones of a ".gitignore" file.
+func TestHg_IgnoreParser(t *testing.T) {
+	object, err := Compile(
+		WithDialect(DialectMercurial),
+		WithLinesFrom(HgIgnoreFile, "syntax: glob", "*.o", "dist"),
+		WithLinesFrom("extra", `\.tmp$`),
+	)
+	assert.Nil(t, err, "err should be nil")
+
+	var parser IgnoreParser = object
+	matches, how := parser.MatchesPathHow("dist/app/main.js")
+	assert.Equal(t, true, matches, "dist/app/main.js should match")
+	assert.Equal(t, HgIgnoreFile, how.Source)
+	assert.Equal(t, 3, how.LineNo)
+	assert.Equal(t, "dist", how.Line)
+	assert.NotNil(t, how.Pattern, "the pattern should have its regexp")
+
+	_, how = parser.MatchesPathHow("a/b.tmp")
+	assert.Equal(t, "extra", how.Source)
+	assert.Equal(t, false, parser.MatchesPath("src/main.go"), "src/main.go should not match")
+}
+
+////////////////////////////////////////////////////////////
@@ -52,6 +52,7 @@ The summarized version of the same has been copied here:
 package ignore
 
 import (
+	"io/fs"
 	"io/ioutil"
 	"os"
 	"regexp"
@@ -66,114 +67,261 @@ type IgnoreParser interface {
 	MatchesPathHow(f string) (bool, *IgnorePattern)
 }
 
+// DirIgnoreParser is an IgnoreParser which can be told explicitly whether a
+// path is a directory, instead of relying on a trailing slash.
+type DirIgnoreParser interface {
+	IgnoreParser
+	MatchesPathIsDir(f string, isDir bool) bool
+	MatchesPathIsDirHow(f string, isDir bool) (bool, *IgnorePattern)
+}
+
 ////////////////////////////////////////////////////////////
 
 // This function pretty much attempts to mimic the parsing rules
-// listed above at the start of this file
-func getPatternFromLine(line string) (*regexp.Regexp, bool) {
-	// Trim OS-specific carriage returns.
-	line = strings.TrimRight(line, "\r")
-
+// listed above at the start of this file. The returned IgnorePattern
+// does not have its Line, LineNo and Pattern fields set, and is nil for
+// blank lines and comments.
+func parsePatternLine(line string) (*IgnorePattern, error) {
 	// Strip comments [Rule 2]
 	if strings.HasPrefix(line, `#`) {
-		return nil, false
+		return nil, nil
 	}
 
-	// Trim string [Rule 3]
-	// TODO: Handle [Rule 3], when the " " is escaped with a \
-	line = strings.Trim(line, " ")
+	// Trim the OS-specific carriage return, and the trailing spaces which
+	// are not escaped with a \ [Rule 3]
+	trimmed := trimTrailingSpaces(strings.TrimSuffix(line, "\r"))
+	stripped := line[len(trimmed):]
+	line = trimmed
 
 	// Exit for no-ops and return nil which will prevent us from
 	// appending a pattern against this line
 	if line == "" {
-		return nil, false
+		return nil, nil
 	}
 
-	// TODO: Handle [Rule 4] which negates the match for patterns leading with "!"
+	// Handle [Rule 4] which negates the match for patterns leading with "!"
 	negatePattern := false
 	if line[0] == '!' {
 		negatePattern = true
 		line = line[1:]
 	}
 
-	// Handle [Rule 2, 4], when # or ! is escaped with a \
-	// Handle [Rule 4] once we tag negatePattern, strip the leading ! char
-	if regexp.MustCompile(`^(\#|\!)`).MatchString(line) {
-		line = line[1:]
-	}
-
-	// If we encounter a foo/*.blah in a folder, prepend the / char
-	if regexp.MustCompile(`([^\/+])/.*\*\.`).MatchString(line) && line[0] != '/' {
-		line = "/" + line
+	// Handle [Rule 5], strip the trailing slash and remember that the
+	// pattern only matches directories
+	dirOnly := false
+	if strings.HasSuffix(line, "/") {
+		dirOnly = true
+		line = strings.TrimSuffix(line, "/")
+		if line == "" {
+			return nil, nil
+		}
 	}
 
-	// Handle escaping the "." char
-	line = regexp.MustCompile(`\.`).ReplaceAllString(line, `\.`)
+	// Handle [Rule 6, 7, 8], a pattern with a slash in it is anchored to the
+	// directory of the .gitignore file, the others match at any depth. The
+	// escaping of a leading # or ! with a \ [Rule 2, 4] is handled along
+	// with the other escapes in globToRegexp.
+	anchored := strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
 
-	magicStar := "#$~"
-
-	// Handle "/**/" usage
-	if strings.HasPrefix(line, "/**/") {
-		line = line[1:]
+	// Invalid globs are detected without converting them to a regex, which
+	// is only done when the patterns are compiled.
+	if err := validateGlob(line); err != nil {
+		return nil, err
 	}
-	line = regexp.MustCompile(`/\*\*/`).ReplaceAllString(line, `(/|/.+/)`)
-	line = regexp.MustCompile(`\*\*/`).ReplaceAllString(line, `(|.`+magicStar+`/)`)
-	line = regexp.MustCompile(`/\*\*`).ReplaceAllString(line, `(|/.`+magicStar+`)`)
-
-	// Handle escaping the "*" char
-	line = regexp.MustCompile(`\\\*`).ReplaceAllString(line, `\`+magicStar)
-	line = regexp.MustCompile(`\*`).ReplaceAllString(line, `([^/]*)`)
-
-	// Handle escaping the "?" char
-	line = strings.Replace(line, "?", `\?`, -1)
 
-	line = strings.Replace(line, magicStar, "*", -1)
+	return &IgnorePattern{
+		Negate:   negatePattern,
+		Stripped: stripped,
+		glob:     line,
+		anchored: anchored,
+		dirOnly:  dirOnly,
+	}, nil
+}
 
-	// Temporary regex
-	var expr = ""
-	if strings.HasSuffix(line, "/") {
-		expr = line + "(|.*)$"
-	} else {
-		expr = line + "(|/.*)$"
+// trimTrailingSpaces removes the trailing spaces from `line`, except for the
+// ones which are escaped with a backslash [Rule 3]. Leading spaces, and other
+// whitespace characters, are significant.
+func trimTrailingSpaces(line string) string {
+	end := len(line)
+	lastSpace := -1
+	for i := 0; i < end; i++ {
+		switch line[i] {
+		case ' ':
+			if lastSpace < 0 {
+				lastSpace = i
+			}
+		case '\\':
+			i++
+			lastSpace = -1
+		default:
+			lastSpace = -1
+		}
 	}
-	if strings.HasPrefix(expr, "/") {
-		expr = "^(|/)" + expr[1:]
-	} else {
-		expr = "^(|.*/)" + expr
+	if lastSpace >= 0 {
+		return line[:lastSpace]
 	}
-	pattern, _ := regexp.Compile(expr)
-
-	return pattern, negatePattern
+	return line
 }
 
 ////////////////////////////////////////////////////////////
 
 // IgnorePattern encapsulates a pattern and if it is a negated pattern.
+// Pattern is a regular expression equivalent to the pattern, for callers
+// which want to use it on their own; it is not used for matching.
 type IgnorePattern struct {
 	Pattern *regexp.Regexp
 	Negate  bool
 	LineNo  int
 	Line    string
+
+	// Source is the path of the ignore file the pattern was read from, or
+	// the label given to CompileIgnoreLinesFrom. It is empty for lines which
+	// were passed directly.
+	Source string
+
+	// Base is the slash separated directory, relative to the root of a
+	// Repository, which the pattern is anchored to. It is empty for the root,
+	// and for patterns compiled on their own.
+	Base string
+
+	// Stripped holds the characters which were removed from the end of Line
+	// before it was parsed: a carriage return, and the trailing spaces which
+	// were not escaped with a backslash [Rule 3].
+	Stripped string
+
+	// glob is the pattern matched by wildmatch, without the leading "!",
+	// the trailing slash and the leading slash.
+	glob string
+
+	// anchored is set for patterns which contain a slash. They are matched
+	// against the whole path instead of its last component [Rule 6, 7].
+	anchored bool
+
+	// dirOnly is set for patterns which end with a slash [Rule 5].
+	dirOnly bool
+
+	// flags are the wildmatch flags added to the ones implied by anchored.
+	flags int
+
+	// docker is set for the patterns of DialectDocker, which are matched
+	// by it instead of wildmatch.
+	docker *dockerPattern
+
+	// re is set for the patterns of DialectMercurial, which are matched by
+	// it instead of wildmatch.
+	re *regexp.Regexp
+}
+
+// matches reports whether the pattern matches the slash separated path `f`,
+// which is a directory if `isDir` is set, without looking at its parents.
+func (ip *IgnorePattern) matches(f string, isDir bool) bool {
+	if ip.docker != nil {
+		return ip.docker.matchesOrParentMatches(f)
+	}
+	if ip.re != nil {
+		return ip.re.MatchString(f)
+	}
+	if ip.dirOnly && !isDir {
+		return false
+	}
+	if !ip.anchored {
+		return wildmatch(ip.glob, f[strings.LastIndexByte(f, '/')+1:], ip.flags)
+	}
+	return wildmatch(ip.glob, f, ip.flags|wmPathname)
+}
+
+// compileRegexp sets the Pattern of the IgnorePattern.
+func (ip *IgnorePattern) compileRegexp() error {
+	if ip.docker != nil {
+		if ip.Pattern = ip.docker.re; ip.Pattern == nil {
+			ip.Pattern = regexp.MustCompile(ip.docker.expr)
+		}
+		return nil
+	}
+	if ip.re != nil {
+		ip.Pattern = ip.re
+		return nil
+	}
+
+	body, err := globToRegexp(ip.glob)
+	if err != nil {
+		return err
+	}
+
+	// The regex is only kept for compatibility, matching is done by
+	// wildmatch. Paths underneath a match are handled by checking their
+	// parent directories in MatchesPathHow [Rule 4].
+	var expr = "^" + body + "$"
+	if !ip.anchored {
+		expr = "^(|.*/)" + body + "$"
+	}
+	if ip.flags&wmCaseFold != 0 {
+		expr = "(?i)" + expr
+	}
+	ip.Pattern, err = regexp.Compile(expr)
+	return err
+}
+
+// setIgnoreCase makes the pattern match regardless of case, or not.
+func (ip *IgnorePattern) setIgnoreCase(ignoreCase bool) {
+	if ignoreCase {
+		ip.flags |= wmCaseFold
+	} else {
+		ip.flags &^= wmCaseFold
+	}
+	if ip.Pattern != nil {
+		ip.compileRegexp()
+	}
 }
 
 // GitIgnore wraps a list of ignore pattern.
 type GitIgnore struct {
 	patterns []*IgnorePattern
+	warnings []*LineError
+
+	// index is used to only try the patterns which can match a given path,
+	// it is rebuilt whenever patterns are added.
+	index *patternIndex
+
+	// ignoreCase is set when the patterns match regardless of case, like
+	// git does with core.ignorecase.
+	ignoreCase bool
+
+	// base is the slash separated directory the patterns are anchored to,
+	// see WithBase. Paths outside of it are never matched.
+	base string
+
+	// separator is the path separator converted to a slash in the matched
+	// paths, see WithSeparator. os.PathSeparator is used if it is 0.
+	separator byte
+
+	// noRegexp is set when the Pattern of the patterns is not compiled,
+	// see WithMatchMode.
+	noRegexp bool
+
+	// dialect is the syntax of the lines, see WithDialect.
+	dialect Dialect
+
+	// includes is set when "#!include:" directives are followed, see
+	// WithIncludes.
+	includes bool
 }
 
 // CompileIgnoreLines accepts a variadic set of strings, and returns a GitIgnore
 // instance which converts and appends the lines in the input to regexp.Regexp
-// patterns held within the GitIgnore objects "patterns" field.
+// patterns held within the GitIgnore objects "patterns" field. Invalid lines
+// are skipped, and can be retrieved with the Warnings method.
 func CompileIgnoreLines(lines ...string) *GitIgnore {
+	return CompileIgnoreLinesFrom("", lines...)
+}
+
+// CompileIgnoreLinesFrom is like CompileIgnoreLines, but attributes the lines
+// to `source`, a label such as "cli-args" saying where they came from. It is
+// reported as the Source of the patterns, and of the warnings.
+func CompileIgnoreLinesFrom(source string, lines ...string) *GitIgnore {
 	gi := &GitIgnore{}
-	for i, line := range lines {
-		pattern, negatePattern := getPatternFromLine(line)
-		if pattern != nil {
-			// LineNo is 1-based numbering to match `git check-ignore -v` output
-			ip := &IgnorePattern{pattern, negatePattern, i + 1, line}
-			gi.patterns = append(gi.patterns, ip)
-		}
-	}
+	gi.compileLines(source, lines)
 	return gi
 }
 
@@ -184,28 +332,176 @@ func CompileIgnoreFile(fpath string) (*GitIgnore, error) {
 	if err != nil {
 		return nil, err
 	}
-
-	s := strings.Split(string(bs), "\n")
-	return CompileIgnoreLines(s...), nil
+	return compileFileAndLines(fpath, bs, nil), nil
 }
 
 // CompileIgnoreFileAndLines accepts a ignore file as the input, parses the
 // lines out of the file and invokes the CompileIgnoreLines method with
-// additional lines.
+// additional lines. The additional lines are numbered from 1, and have no
+// Source.
 func CompileIgnoreFileAndLines(fpath string, lines ...string) (*GitIgnore, error) {
 	bs, err := ioutil.ReadFile(fpath)
 	if err != nil {
 		return nil, err
 	}
+	return compileFileAndLines(fpath, bs, lines), nil
+}
+
+// CompileIgnoreFS is like CompileIgnoreFile, but reads the ignore file `name`
+// from `fsys`, which lets the rules come from an embed.FS, a zip archive or
+// any other file system.
+func CompileIgnoreFS(fsys fs.FS, name string) (*GitIgnore, error) {
+	return CompileIgnoreFSAndLines(fsys, name)
+}
+
+// CompileIgnoreFSAndLines is like CompileIgnoreFileAndLines, but reads the
+// ignore file `name` from `fsys`.
+func CompileIgnoreFSAndLines(fsys fs.FS, name string, lines ...string) (*GitIgnore, error) {
+	bs, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	return compileFileAndLines(name, bs, lines), nil
+}
+
+// compileFileAndLines compiles the contents `bs` of the ignore file `source`,
+// followed by the additional `lines`. The additional lines have no source,
+// and are numbered on their own.
+func compileFileAndLines(source string, bs []byte, lines []string) *GitIgnore {
+	gi := &GitIgnore{}
+	gi.compileLines(source, strings.Split(string(bs), "\n"))
+	if len(lines) > 0 {
+		gi.compileLines("", lines)
+	}
+	return gi
+}
+
+// CompileIgnoreLinesStrict is like CompileIgnoreLines, but fails with a
+// *CompileError if any of the lines is invalid.
+func CompileIgnoreLinesStrict(lines ...string) (*GitIgnore, error) {
+	return CompileIgnoreLines(lines...).strict()
+}
 
-	gi := CompileIgnoreLines(append(strings.Split(string(bs), "\n"), lines...)...)
+// CompileIgnoreFileStrict is like CompileIgnoreFile, but fails with a
+// *CompileError if any of the lines in the file is invalid.
+func CompileIgnoreFileStrict(fpath string) (*GitIgnore, error) {
+	gi, err := CompileIgnoreFile(fpath)
+	if err != nil {
+		return nil, err
+	}
+	return gi.strict()
+}
+
+// CompileIgnoreFileAndLinesStrict is like CompileIgnoreFileAndLines, but fails
+// with a *CompileError if any of the lines is invalid.
+func CompileIgnoreFileAndLinesStrict(fpath string, lines ...string) (*GitIgnore, error) {
+	gi, err := CompileIgnoreFileAndLines(fpath, lines...)
+	if err != nil {
+		return nil, err
+	}
+	return gi.strict()
+}
+
+// compileLines appends the patterns parsed from `lines` to the GitIgnore, and
+// attributes them to `source`. Invalid lines are recorded as warnings.
+func (gi *GitIgnore) compileLines(source string, lines []string) {
+	gi.compileLinesIn(newIncluder(nil, source), source, lines)
+}
+
+// compileLinesIn is like compileLines, and reads the files named by the
+// "#!include:" directives of `lines` with `inc`, if they are enabled.
+func (gi *GitIgnore) compileLinesIn(inc *includer, source string, lines []string) {
+	parse := parsePatternLine
+	switch gi.dialect {
+	case DialectDocker:
+		parse = parseDockerLine
+	case DialectMercurial:
+		parse = newHgParser().parseLine
+	}
+
+	for i, line := range lines {
+		// LineNo is 1-based numbering to match `git check-ignore -v` output
+		lineNo := i + 1
+
+		if i == 0 && gi.dialect == DialectDocker {
+			// Docker skips a UTF-8 byte order mark.
+			line = strings.TrimPrefix(line, "\ufeff")
+		}
+
+		var ip *IgnorePattern
+		var err error
+		if gi.includes && strings.HasPrefix(line, includeDirective) {
+			err = gi.include(inc, source, strings.TrimSpace(line[len(includeDirective):]))
+		} else {
+			ip, err = parse(line)
+		}
+		if err == nil && ip != nil {
+			ip.Base = gi.base
+			if gi.ignoreCase {
+				ip.flags |= wmCaseFold
+			}
+			if !gi.noRegexp {
+				err = ip.compileRegexp()
+			}
+		}
+		if err != nil {
+			gi.warnings = append(gi.warnings, &LineError{
+				Source: source,
+				LineNo: lineNo,
+				Line:   line,
+				Err:    err,
+			})
+			continue
+		}
+		if ip != nil {
+			ip.LineNo, ip.Line, ip.Source = lineNo, line, source
+			gi.patterns = append(gi.patterns, ip)
+		}
+	}
+	gi.index = newPatternIndex(gi.patterns, gi.ignoreCase)
+}
+
+// setIgnoreCase makes the GitIgnore match paths regardless of the case of
+// their ASCII letters, in literals, wildcards and bracket expressions alike,
+// as git does on checkouts with core.ignorecase set. It has no effect on the
+// GitIgnores of the other dialects, which are always case sensitive.
+func (gi *GitIgnore) setIgnoreCase(ignoreCase bool) {
+	if gi.dialect != DialectGit {
+		return
+	}
+	gi.ignoreCase = ignoreCase
+	for _, ip := range gi.patterns {
+		ip.setIgnoreCase(ignoreCase)
+	}
+	gi.index = newPatternIndex(gi.patterns, ignoreCase)
+}
+
+// setBase records the directory `base` of a Repository which the patterns of
+// the GitIgnore are anchored to.
+func (gi *GitIgnore) setBase(base string) {
+	for _, ip := range gi.patterns {
+		ip.Base = base
+	}
+}
+
+// strict returns the GitIgnore, or a *CompileError if it has any warnings.
+func (gi *GitIgnore) strict() (*GitIgnore, error) {
+	if len(gi.warnings) > 0 {
+		return nil, &CompileError{Errors: gi.warnings}
+	}
 	return gi, nil
 }
 
+// Warnings returns the lines which were skipped while compiling the GitIgnore
+// because they are invalid, in the order they were encountered.
+func (gi *GitIgnore) Warnings() []*LineError {
+	return gi.warnings
+}
+
 ////////////////////////////////////////////////////////////
 
 // MatchesPath returns true if the given GitIgnore structure would target
-// a given path string `f`.
+// a given path string `f`. A trailing slash marks `f` as a directory.
 func (gi *GitIgnore) MatchesPath(f string) bool {
 	matchesPath, _ := gi.MatchesPathHow(f)
 	return matchesPath
@@ -215,25 +511,127 @@ func (gi *GitIgnore) MatchesPath(f string) bool {
 // a given path string `f`.
 // The IgnorePattern has the Line, LineNo fields.
 func (gi *GitIgnore) MatchesPathHow(f string) (bool, *IgnorePattern) {
+	f, isDir := splitDirSuffix(f)
+	return gi.MatchesPathIsDirHow(f, isDir)
+}
+
+// MatchesPathIsDir returns true if the given GitIgnore structure would target
+// the path `f`, which is a directory if `isDir` is set. Unlike MatchesPath, a
+// trailing slash on `f` is not significant.
+func (gi *GitIgnore) MatchesPathIsDir(f string, isDir bool) bool {
+	matchesPath, _ := gi.MatchesPathIsDirHow(f, isDir)
+	return matchesPath
+}
+
+// MatchesPathIsDirHow returns true, `pattern` if the given GitIgnore structure
+// would target the path `f`, which is a directory if `isDir` is set.
+func (gi *GitIgnore) MatchesPathIsDirHow(f string, isDir bool) (bool, *IgnorePattern) {
+	return gi.Match(f, isDir).how()
+}
+
+// Match returns whether the GitIgnore ignores the path `f`, which is a
+// directory if `isDir` is set, re-includes it, or does not mention it, along
+// with the pattern which decided.
+func (gi *GitIgnore) Match(f string, isDir bool) MatchResult {
+	f, ok := gi.relPath(f)
+	if !ok {
+		return MatchResult{Status: NoMatch}
+	}
+	if gi.dialect == DialectDocker {
+		// The patterns match the parents of `f` on their own, and a later
+		// exception re-includes `f` even if a parent is excluded.
+		return resultOf(gi.lastMatch(f, isDir))
+	}
+	return matchWithParents(gi, f, isDir)
+}
+
+// lastMatch returns the last pattern in the GitIgnore which matches the path
+// `f`, regardless of whether it is negated. A nil return means that none of
+// the patterns mention `f`.
+func (gi *GitIgnore) lastMatch(f string, isDir bool) *IgnorePattern {
+	if gi.index != nil {
+		return gi.index.lastMatch(gi.patterns, f, isDir)
+	}
+	for i := len(gi.patterns) - 1; i >= 0; i-- {
+		if ip := gi.patterns[i]; ip.matches(f, isDir) {
+			return ip
+		}
+	}
+	return nil
+}
+
+////////////////////////////////////////////////////////////
+
+// splitDirSuffix strips a trailing slash (or OS-specific path separator) from
+// `f`, and reports whether there was one.
+func splitDirSuffix(f string) (string, bool) {
+	for _, sep := range []string{"/", string(os.PathSeparator)} {
+		if strings.HasSuffix(f, sep) {
+			return strings.TrimSuffix(f, sep), true
+		}
+	}
+	return f, false
+}
+
+// cleanPath converts `f` into the slash separated form which the patterns are
+// matched against, without leading or trailing slashes.
+func cleanPath(f string) string {
 	// Replace OS-specific path separator.
-	f = strings.Replace(f, string(os.PathSeparator), "/", -1)
+	if os.PathSeparator != '/' {
+		f = strings.Replace(f, string(os.PathSeparator), "/", -1)
+	}
+	return strings.Trim(f, "/")
+}
 
-	matchesPath := false
-	var mip *IgnorePattern
-	for _, ip := range gi.patterns {
-		if ip.Pattern.MatchString(f) {
-			// If this is a regular target (not negated with a gitignore
-			// exclude "!" etc)
-			if !ip.Negate {
-				matchesPath = true
-				mip = ip
-			} else if matchesPath {
-				// Negated pattern, and matchesPath is already set
-				matchesPath = false
-			}
+// relPath cleans the path `f` like cleanPath, and makes it relative to the
+// base directory of the GitIgnore. It reports false if `f` is not inside of
+// the base directory.
+func (gi *GitIgnore) relPath(f string) (string, bool) {
+	if gi.separator != 0 && gi.separator != '/' {
+		f = strings.Trim(strings.Replace(f, string(gi.separator), "/", -1), "/")
+	} else if gi.separator == '/' {
+		f = strings.Trim(f, "/")
+	} else {
+		f = cleanPath(f)
+	}
+	if gi.base == "" {
+		return f, true
+	}
+	if len(f) <= len(gi.base) || f[len(gi.base)] != '/' || !gi.sameBase(f[:len(gi.base)]) {
+		return f, false
+	}
+	return f[len(gi.base)+1:], true
+}
+
+// sameBase reports whether the directory `dir` is the base of the GitIgnore,
+// regardless of case if the GitIgnore ignores case.
+func (gi *GitIgnore) sameBase(dir string) bool {
+	if gi.ignoreCase {
+		return strings.EqualFold(dir, gi.base)
+	}
+	return dir == gi.base
+}
+
+// levelMatcher is implemented by the types which can report the pattern
+// deciding the fate of a single path, without looking at its parents.
+type levelMatcher interface {
+	lastMatch(f string, isDir bool) *IgnorePattern
+}
+
+// matchWithParents applies [Rule 4] on top of a levelMatcher. Every parent
+// directory of the slash separated path `f` is checked first, from the top
+// down, and the first excluded one excludes `f` as well since git never
+// looks inside of excluded directories.
+func matchWithParents(m levelMatcher, f string, isDir bool) MatchResult {
+	for i := 0; i < len(f); i++ {
+		if f[i] != '/' {
+			continue
+		}
+		if ip := m.lastMatch(f[:i], true); ip != nil && !ip.Negate {
+			return MatchResult{Status: Ignored, Pattern: ip}
 		}
 	}
-	return matchesPath, mip
+	return resultOf(m.lastMatch(f, isDir))
 }
 
 ////////////////////////////////////////////////////////////
@@ -0,0 +1,87 @@
+// Implement tests, ported from git's t/t0008-ignores.sh
+package ignore
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGit_ExcludedParentDirectory(test *testing.T) {
+	lines := []string{"build/", "!build/keep.txt"}
+	object := CompileIgnoreLines(lines...)
+
+	shouldMatch(test, object, "build/")
+	shouldMatch(test, object, "build/keep.txt")
+	shouldMatch(test, object, "build/other.txt")
+	shouldMatch(test, object, "sub/build/keep.txt")
+	shouldNotMatch(test, object, "keep.txt")
+}
+
+func TestGit_ExcludedParentDirectoryHow(test *testing.T) {
+	lines := []string{"build/", "!build/keep.txt"}
+	object := CompileIgnoreLines(lines...)
+
+	matchesPath, reason := object.MatchesPathHow("build/keep.txt")
+	assert.Equal(test, true, matchesPath, "build/keep.txt should match")
+	assert.NotNil(test, reason, "reason should not be nil")
+	assert.Equal(test, 1, reason.LineNo, "should match with line 1")
+}
+
+// test_expect_success 'directories and ** matches'
+func TestGit_DirectoriesAndDoubleStar(test *testing.T) {
+	lines := []string{"data/**", "!data/**/", "!data/**/*.txt"}
+	object := CompileIgnoreLines(lines...)
+
+	shouldNotMatch(test, object, "file")
+	shouldMatch(test, object, "data/file")
+	shouldMatch(test, object, "data/data1/file1")
+	shouldNotMatch(test, object, "data/data1/file1.txt")
+	shouldMatch(test, object, "data/data2/file2")
+	shouldNotMatch(test, object, "data/data2/file2.txt")
+}
+
+// Re-including a directory makes the patterns about its contents effective
+func TestGit_ReincludedDirectory(test *testing.T) {
+	lines := []string{"/*", "!/foo", "/foo/*", "!/foo/bar"}
+	object := CompileIgnoreLines(lines...)
+
+	shouldMatch(test, object, "a")
+	shouldMatch(test, object, "a/b")
+	shouldMatch(test, object, "foo/baz")
+	shouldMatch(test, object, "foo/baz/qux")
+	shouldNotMatch(test, object, "foo")
+	shouldNotMatch(test, object, "foo/bar")
+	shouldNotMatch(test, object, "foo/bar/qux")
+}
+
+// The tree used by most of t0008, with its nested .gitignore files
+func TestGit_NestedIgnoredDirectory(test *testing.T) {
+	root := writeTestTree(test, map[string]string{
+		".gitignore":   "one\nignored-*\ntop-level-dir/\n",
+		"a/.gitignore": "two*\n*three\n",
+		"a/b/.gitignore": "four\nfive\n# this comment should affect the line numbers\nsix\n" +
+			"ignored-dir/\n# and so should this blank line:\n\n!on*\n!two\n",
+		"a/b/ignored-dir/.gitignore": "seven\n",
+	})
+	defer os.RemoveAll(root)
+
+	object, err := CompileRepository(root)
+	assert.Nil(test, err, "err should be nil")
+
+	// test_expect_success_multi 'nested include'
+	assert.Equal(test, false, object.MatchesPath("a/b/one"), "a/b/one should not match")
+	assert.Equal(test, true, object.MatchesPath("a/one"), "a/one should match")
+	assert.Equal(test, true, object.MatchesPath("a/b/twooo"), "a/b/twooo should match")
+	assert.Equal(test, false, object.MatchesPath("a/b/two"), "a/b/two should not match")
+
+	// test_expect_success 'multiple files inside ignored sub-directory'
+	for _, f := range []string{"a/b/ignored-dir/foo", "a/b/ignored-dir/twoooo", "a/b/ignored-dir/seven"} {
+		matchesPath, reason := object.MatchesPathHow(f)
+		assert.Equal(test, true, matchesPath, f+" should match")
+		assert.NotNil(test, reason, "reason should not be nil")
+		assert.Equal(test, 5, reason.LineNo, "should match with line 5")
+		assert.Equal(test, "ignored-dir/", reason.Line, "should match with line ignored-dir/")
+	}
+}
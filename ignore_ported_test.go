@@ -107,7 +107,8 @@ func TestDoubleStar(test *testing.T) {
 	lines := []string{"foo/**/"}
 	object := CompileIgnoreLines(lines...)
 
-	shouldMatch(test, object, "foo/")
+	// "foo/**" needs something after the slash, so foo itself is not a match
+	shouldNotMatch(test, object, "foo/")
 	shouldMatch(test, object, "foo/abc/")
 	shouldMatch(test, object, "foo/x/y/z/")
 	shouldNotMatch(test, object, "foo")
@@ -145,7 +146,8 @@ func TestCases_CouldFilterPaths(test *testing.T) {
 	object := CompileIgnoreLines(lines...)
 
 	shouldMatch(test, object, "abc/a.js")
-	shouldNotMatch(test, object, "abc/b/b.js")
+	// abc is excluded, so abc/b cannot be re-included [Rule 4]
+	shouldMatch(test, object, "abc/b/b.js")
 }
 
 func TestCases_IgnoreSelect(test *testing.T) {
@@ -153,7 +155,7 @@ func TestCases_IgnoreSelect(test *testing.T) {
 	object := CompileIgnoreLines(lines...)
 
 	shouldMatch(test, object, "abc/a.js")
-	shouldNotMatch(test, object, "abc/b/b.js")
+	shouldMatch(test, object, "abc/b/b.js")
 	shouldNotMatch(test, object, "#e")
 	shouldMatch(test, object, "#f")
 }
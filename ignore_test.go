@@ -7,8 +7,11 @@ import (
 	"io/ioutil"
 	"path/filepath"
 
+	"errors"
 	"fmt"
+	"io/fs"
 	"testing"
+	"testing/fstest"
 
 	"runtime"
 
@@ -246,6 +249,39 @@ func TestCompileIgnoreFileAndLines_InvalidFile(t *testing.T) {
 	assert.NotNil(t, err, "err should be unknown file / dir")
 }
 
+func TestCompileIgnoreFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"conf/.gitignore": {Data: []byte("*.log\n!keep.log\n[z-a\n")},
+	}
+
+	object, err := CompileIgnoreFS(fsys, "conf/.gitignore")
+	assert.Nil(t, err, "err should be nil")
+	assert.NotNil(t, object, "object should not be nil")
+
+	assert.Equal(t, true, object.MatchesPath("a/debug.log"), "a/debug.log should match")
+	assert.Equal(t, false, object.MatchesPath("a/keep.log"), "a/keep.log should not match")
+	assert.Equal(t, 1, len(object.Warnings()), "the invalid line should be a warning")
+	assert.Equal(t, "conf/.gitignore", object.Warnings()[0].Source, "the warning should name the file")
+
+	object, err = CompileIgnoreFS(fsys, "missing/.gitignore")
+	assert.Nil(t, object, "object should be nil")
+	assert.True(t, errors.Is(err, fs.ErrNotExist), "err should be unknown file")
+}
+
+func TestCompileIgnoreFSAndLines(t *testing.T) {
+	fsys := fstest.MapFS{
+		".gitignore": {Data: []byte("/*.c\n")},
+	}
+
+	object, err := CompileIgnoreFSAndLines(fsys, ".gitignore", "**/foo", "!bar.c")
+	assert.Nil(t, err, "err should be nil")
+	assert.NotNil(t, object, "object should not be nil")
+
+	assert.Equal(t, true, object.MatchesPath("hello.c"), "hello.c should match")
+	assert.Equal(t, false, object.MatchesPath("bar.c"), "bar.c should not match")
+	assert.Equal(t, true, object.MatchesPath("baz/foo"), "baz/foo should match")
+}
+
 func ExampleCompileIgnoreLines() {
 	ignoreObject := CompileIgnoreLines([]string{"node_modules", "*.out", "foo/*.c"}...)
 
@@ -395,3 +431,187 @@ func TestMatchesLineNumbers(t *testing.T) {
 	assert.Equal(t, false, matchesPath, "should only ignore top level foo directories- not nested")
 	assert.Nil(t, reason, "reason should be nil as no match should happen")
 }
+
+// Validate that patterns remember where they came from
+func TestMatchesSource(t *testing.T) {
+	writeFileToTestDir("test.gitignore", "*.o\n# comment\n/build\n")
+	defer cleanupTestDir()
+
+	object, err := CompileIgnoreFileAndLines("./test_fixtures/test.gitignore", "*.tmp", "[z-a")
+	assert.Nil(t, err, "err should be nil")
+
+	_, reason := object.MatchesPathHow("build/a.c")
+	assert.Equal(t, "./test_fixtures/test.gitignore", reason.Source, "should match in the file")
+	assert.Equal(t, 3, reason.LineNo, "should match with line 3 of the file")
+	assert.Equal(t, "", reason.Base, "should not have a base directory")
+
+	_, reason = object.MatchesPathHow("a.tmp")
+	assert.Equal(t, "", reason.Source, "should match in the additional lines")
+	assert.Equal(t, 1, reason.LineNo, "should match with line 1 of the additional lines")
+	assert.Equal(t, 2, object.Warnings()[0].LineNo, "should warn about line 2 of the additional lines")
+
+	object = CompileIgnoreLinesFrom("cli-args", "*.o", "*.tmp", "[z-a")
+	_, reason = object.MatchesPathHow("a.tmp")
+	assert.Equal(t, "cli-args", reason.Source, "should match in the labeled lines")
+	assert.Equal(t, 2, reason.LineNo, "should match with line 2")
+	assert.Equal(t, "cli-args", object.Warnings()[0].Source, "should warn about the labeled lines")
+}
+
+func TestCompile_IgnoreCaseRules(t *testing.T) {
+	lines := []string{"Makefile.bak", "*.LOG", "/Build/", "[A-C]*.tmp", "!keep.log"}
+	object, err := Compile(WithLines(lines...), WithIgnoreCase(false))
+	assert.Nil(t, err, "err should be nil")
+	assert.Equal(t, false, object.MatchesPath("makefile.BAK"), "makefile.BAK should not match")
+	assert.Equal(t, false, object.MatchesPath("debug.log"), "debug.log should not match")
+	assert.Equal(t, false, object.MatchesPath("Debug.Log"), "Debug.Log should not match")
+	assert.Equal(t, true, object.MatchesPath("debug.LOG"), "debug.LOG should match")
+	assert.Equal(t, false, object.index.ignoreCase, "the index should be case sensitive")
+
+	object, err = Compile(WithLines(lines...), WithIgnoreCase(true))
+	assert.Nil(t, err, "err should be nil")
+	assert.Equal(t, true, object.MatchesPath("src/makefile.BAK"), "src/makefile.BAK should match")
+	assert.Equal(t, true, object.MatchesPath("debug.log"), "debug.log should match")
+	assert.Equal(t, true, object.MatchesPath("build/out.bin"), "build/out.bin should match")
+	assert.Equal(t, true, object.MatchesPath("BUILD/"), "BUILD/ should match")
+	assert.Equal(t, true, object.MatchesPath("a.tmp"), "a.tmp should match")
+	assert.Equal(t, false, object.MatchesPath("d.tmp"), "d.tmp should not match")
+	assert.Equal(t, false, object.MatchesPath("KEEP.LOG"), "KEEP.LOG should not match")
+
+	_, how := object.MatchesPathHow("Debug.Log")
+	assert.Equal(t, true, how.Pattern.MatchString("Debug.Log"), "the regexp should ignore case as well")
+	assert.Equal(t, true, object.index.ignoreCase, "the index should ignore case")
+}
+
+func TestMatchesPathIsDir(t *testing.T) {
+	gitIgnore := []string{"foo/", "bar", "baz/**/", "/qux/*/"}
+	object := CompileIgnoreLines(gitIgnore...)
+
+	var _ DirIgnoreParser = object
+
+	// foo/ only matches directories [Rule 5]
+	assert.Equal(t, true, object.MatchesPathIsDir("foo", true), "foo should match as a directory")
+	assert.Equal(t, false, object.MatchesPathIsDir("foo", false), "foo should not match as a file")
+	assert.Equal(t, false, object.MatchesPathIsDir("foo/", false), "foo/ should not match as a file")
+	assert.Equal(t, true, object.MatchesPathIsDir("a/foo", true), "a/foo should match as a directory")
+	assert.Equal(t, true, object.MatchesPathIsDir("a/foo/b", false), "a/foo/b should match as a file")
+
+	// bar matches both files and directories
+	assert.Equal(t, true, object.MatchesPathIsDir("bar", true), "bar should match as a directory")
+	assert.Equal(t, true, object.MatchesPathIsDir("bar", false), "bar should match as a file")
+
+	assert.Equal(t, true, object.MatchesPathIsDir("baz/a", true), "baz/a should match as a directory")
+	assert.Equal(t, false, object.MatchesPathIsDir("baz/a", false), "baz/a should not match as a file")
+	assert.Equal(t, true, object.MatchesPathIsDir("baz/a/b", false), "baz/a/b should match as a file")
+
+	assert.Equal(t, true, object.MatchesPathIsDir("qux/a", true), "qux/a should match as a directory")
+	assert.Equal(t, false, object.MatchesPathIsDir("qux/a", false), "qux/a should not match as a file")
+	assert.Equal(t, false, object.MatchesPathIsDir("qux", true), "qux should not match as a directory")
+
+	matchesPath, reason := object.MatchesPathIsDirHow("x/foo", true)
+	assert.Equal(t, true, matchesPath, "x/foo should match as a directory")
+	assert.NotNil(t, reason, "reason should not be nil")
+	assert.Equal(t, 1, reason.LineNo, "should match with line 1")
+}
+
+func TestQuestionMarkWildcard(t *testing.T) {
+	gitIgnore := []string{"*.sw?", "file?.txt", `lit\?.md`, "a?b"}
+	object := CompileIgnoreLines(gitIgnore...)
+
+	assert.Equal(t, true, object.MatchesPath("main.go.swp"), "main.go.swp should match")
+	assert.Equal(t, true, object.MatchesPath("dir/.main.go.swo"), "dir/.main.go.swo should match")
+	assert.Equal(t, false, object.MatchesPath("main.go.sw"), "main.go.sw should not match")
+	assert.Equal(t, false, object.MatchesPath("main.go.swpx"), "main.go.swpx should not match")
+
+	assert.Equal(t, true, object.MatchesPath("file1.txt"), "file1.txt should match")
+	assert.Equal(t, true, object.MatchesPath("fileA.txt"), "fileA.txt should match")
+	assert.Equal(t, false, object.MatchesPath("file.txt"), "file.txt should not match")
+	assert.Equal(t, false, object.MatchesPath("file12.txt"), "file12.txt should not match")
+
+	// An escaped "?" only matches itself
+	assert.Equal(t, true, object.MatchesPath("lit?.md"), "lit?.md should match")
+	assert.Equal(t, false, object.MatchesPath("litx.md"), "litx.md should not match")
+
+	// "?" never matches a "/"
+	assert.Equal(t, true, object.MatchesPath("axb"), "axb should match")
+	assert.Equal(t, false, object.MatchesPath("a/b"), "a/b should not match")
+}
+
+// Validate the handling of leading and trailing spaces [Rule 3]
+func TestCompileIgnoreLines_HandleTrailingSpaces(t *testing.T) {
+	gitIgnore := []string{"trailing   ", `escaped\ `, `both\  `, "  leading", "tab\t", "crlf \r"}
+	object := CompileIgnoreLines(gitIgnore...)
+
+	assert.Equal(t, true, object.MatchesPath("trailing"), "trailing should match")
+	assert.Equal(t, false, object.MatchesPath("trailing "), "'trailing ' should not match")
+
+	assert.Equal(t, true, object.MatchesPath("escaped "), "'escaped ' should match")
+	assert.Equal(t, false, object.MatchesPath("escaped"), "escaped should not match")
+
+	assert.Equal(t, true, object.MatchesPath("both "), "'both ' should match")
+	assert.Equal(t, false, object.MatchesPath("both  "), "'both  ' should not match")
+
+	assert.Equal(t, true, object.MatchesPath("  leading"), "'  leading' should match")
+	assert.Equal(t, false, object.MatchesPath("leading"), "leading should not match")
+
+	assert.Equal(t, true, object.MatchesPath("tab\t"), "'tab\\t' should match")
+	assert.Equal(t, false, object.MatchesPath("tab"), "tab should not match")
+
+	assert.Equal(t, true, object.MatchesPath("crlf"), "crlf should match")
+
+	stripped := []string{"   ", "", " ", "", "", " \r"}
+	for i, ip := range object.patterns {
+		assert.Equal(t, gitIgnore[i], ip.Line, "should keep the original line")
+		assert.Equal(t, stripped[i], ip.Stripped, "should report the stripped characters of %q", ip.Line)
+	}
+}
+
+// Validate the strict compile functions and the lenient warnings
+func TestCompileIgnoreLinesStrict(t *testing.T) {
+	object, err := CompileIgnoreLinesStrict("*.o", "!keep.o", "build/")
+	assert.Nil(t, err, "err should be nil")
+	assert.NotNil(t, object, "object should not be nil")
+	assert.Equal(t, 0, len(object.Warnings()), "should not have warnings")
+
+	lines := []string{"a[b", "ok", `foo\`}
+	object, err = CompileIgnoreLinesStrict(lines...)
+	assert.Nil(t, object, "object should be nil")
+	assert.NotNil(t, err, "err should list the invalid lines")
+
+	compileErr, ok := err.(*CompileError)
+	assert.Equal(t, true, ok, "err should be a *CompileError")
+	assert.Equal(t, 2, len(compileErr.Errors), "should have two invalid lines")
+	assert.Equal(t, 1, compileErr.Errors[0].LineNo, "should report line 1")
+	assert.Equal(t, "a[b", compileErr.Errors[0].Line, "should report line a[b")
+	assert.Equal(t, 3, compileErr.Errors[1].LineNo, "should report line 3")
+	assert.Equal(t, `line 3: pattern ends with an unescaped backslash: "foo\\"`, compileErr.Errors[1].Error())
+
+	// The lenient version keeps the valid lines around
+	object = CompileIgnoreLines(lines...)
+	assert.Equal(t, 1, len(object.patterns), "should have one pattern")
+	assert.Equal(t, compileErr.Errors, object.Warnings(), "should expose the same warnings")
+	assert.Equal(t, true, object.MatchesPath("ok"), "ok should match")
+}
+
+func TestCompileIgnoreFileStrict(t *testing.T) {
+	writeFileToTestDir("test.gitignore", "*.o\n[[:nope:]]\n")
+	defer cleanupTestDir()
+
+	fpath := "./test_fixtures/test.gitignore"
+	object, err := CompileIgnoreFileStrict(fpath)
+	assert.Nil(t, object, "object should be nil")
+	assert.NotNil(t, err, "err should list the invalid lines")
+	assert.Equal(t, fpath+`:2: unknown character class "nope": "[[:nope:]]"`, err.Error())
+
+	object, err = CompileIgnoreFileAndLinesStrict(fpath, "bad[")
+	assert.Nil(t, object, "object should be nil")
+	assert.Equal(t, 2, len(err.(*CompileError).Errors), "should have two invalid lines")
+
+	object, err = CompileIgnoreFileAndLines(fpath, "bad[")
+	assert.Nil(t, err, "err should be nil")
+	assert.Equal(t, 2, len(object.Warnings()), "should have two warnings")
+	assert.Equal(t, fpath, object.Warnings()[0].Source, "should attribute the file line to the file")
+	assert.Equal(t, "", object.Warnings()[1].Source, "should not attribute the extra line to the file")
+
+	_, err = CompileIgnoreFileStrict("./test_fixtures/invalid.file")
+	assert.NotNil(t, err, "err should be unknown file / dir")
+}
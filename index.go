@@ -0,0 +1,207 @@
+package ignore
+
+import (
+	"strings"
+)
+
+////////////////////////////////////////////////////////////
+
+// maxPrefixKey is the length beyond which the literal prefixes of the
+// patterns in `patternIndex.namePrefixes` are truncated to form their key.
+const maxPrefixKey = 8
+
+// patternIndex narrows down the patterns of a GitIgnore which can possibly
+// match a path, so that large ignore files do not need a full scan for every
+// lookup. Each list holds indices into the patterns, in ascending order.
+type patternIndex struct {
+	// basenames holds the unanchored patterns without wildcards, such as
+	// "node_modules", keyed by the name they match.
+	basenames map[string][]int
+
+	// extensions holds the unanchored patterns such as "*.o" or "*.tar.gz",
+	// keyed by what follows the last "." ("o" and "gz").
+	extensions map[string][]int
+
+	// namePrefixes holds the unanchored patterns which start with a literal
+	// prefix followed by a wildcard, such as "cache-*", keyed by the prefix
+	// truncated to maxPrefixKey bytes.
+	namePrefixes map[string][]int
+
+	// namePrefixLens has the bit `1 << n` set when a key of `namePrefixes`
+	// is `n` bytes long.
+	namePrefixLens uint
+
+	// parents holds the patterns such as "**/gen/*.go", which match a path
+	// whose parent directory has a literal name, keyed by it.
+	parents map[string][]int
+
+	// prefixes holds the anchored patterns which start with a literal path
+	// component, such as "/build/**" or "docs/*.html", keyed by it.
+	prefixes map[string][]int
+
+	// others holds the patterns which have to be tried against every path.
+	others []int
+
+	// ignoreCase is set when the keys are in lower case, and paths have to
+	// be looked up in lower case as well.
+	ignoreCase bool
+}
+
+// newPatternIndex classifies each of the `patterns`, which match regardless
+// of case if `ignoreCase` is set.
+func newPatternIndex(patterns []*IgnorePattern, ignoreCase bool) *patternIndex {
+	idx := &patternIndex{
+		basenames:    map[string][]int{},
+		extensions:   map[string][]int{},
+		namePrefixes: map[string][]int{},
+		parents:      map[string][]int{},
+		prefixes:     map[string][]int{},
+		ignoreCase:   ignoreCase,
+	}
+	for i, ip := range patterns {
+		glob := ip.glob
+		if ignoreCase {
+			glob = strings.ToLower(glob)
+		}
+		switch {
+		case ip.re != nil:
+			// The regexps of DialectMercurial are opaque.
+			idx.others = append(idx.others, i)
+
+		case !ip.anchored:
+			idx.addName(glob, i)
+
+		case ip.docker == nil && strings.HasPrefix(glob, "**/"):
+			// [Rule 9.i] the leading "**/" matches any parent directories,
+			// so what follows it has to match the end of the path. The
+			// patterns of DialectDocker also match the parents of a path.
+			idx.addSuffix(glob, i)
+
+		case strings.IndexByte(glob, '/') != 0 && isLiteral(firstComponent(glob)):
+			first := firstComponent(glob)
+			idx.prefixes[first] = append(idx.prefixes[first], i)
+
+		default:
+			idx.others = append(idx.others, i)
+		}
+	}
+	return idx
+}
+
+// addName classifies the pattern `i`, whose glob `name` has to match the last
+// component of a path.
+func (idx *patternIndex) addName(name string, i int) {
+	prefix := name
+	if end := strings.IndexAny(name, `*?[\`); end >= 0 {
+		prefix = name[:end]
+	}
+	switch {
+	case prefix == name:
+		idx.basenames[name] = append(idx.basenames[name], i)
+
+	case strings.HasPrefix(name, "*") && isLiteral(name[1:]) && strings.Contains(name, "."):
+		ext := name[strings.LastIndexByte(name, '.')+1:]
+		idx.extensions[ext] = append(idx.extensions[ext], i)
+
+	case prefix != "":
+		if len(prefix) > maxPrefixKey {
+			prefix = prefix[:maxPrefixKey]
+		}
+		idx.namePrefixes[prefix] = append(idx.namePrefixes[prefix], i)
+		idx.namePrefixLens |= 1 << uint(len(prefix))
+
+	default:
+		idx.others = append(idx.others, i)
+	}
+}
+
+// addSuffix classifies the pattern `i`, whose glob starts with "**/".
+func (idx *patternIndex) addSuffix(glob string, i int) {
+	for strings.HasPrefix(glob, "**/") {
+		glob = glob[len("**/"):]
+	}
+	if strings.Contains(glob, "**") {
+		// The rest may match any number of path components.
+		idx.others = append(idx.others, i)
+		return
+	}
+
+	slash := strings.LastIndexByte(glob, '/')
+	if slash < 0 {
+		idx.addName(glob, i)
+		return
+	}
+	dir := glob[:slash]
+	parent := dir[strings.LastIndexByte(dir, '/')+1:]
+	if isLiteral(parent) {
+		idx.parents[parent] = append(idx.parents[parent], i)
+	} else {
+		idx.addName(glob[slash+1:], i)
+	}
+}
+
+// isLiteral reports whether `glob` only matches itself.
+func isLiteral(glob string) bool {
+	return strings.IndexAny(glob, `*?[\`) < 0
+}
+
+// firstComponent returns the part of the slash separated `f` before its first
+// slash.
+func firstComponent(f string) string {
+	if i := strings.IndexByte(f, '/'); i >= 0 {
+		return f[:i]
+	}
+	return f
+}
+
+// lastMatch returns the last of the `patterns` matching the path `f`, like
+// GitIgnore.lastMatch, while only trying the candidates from the index. The
+// candidate lists are merged from their ends, so the first match is the last
+// one in the file.
+func (idx *patternIndex) lastMatch(patterns []*IgnorePattern, f string, isDir bool) *IgnorePattern {
+	key := f
+	if idx.ignoreCase {
+		key = strings.ToLower(f)
+	}
+	slash := strings.LastIndexByte(key, '/')
+	base := key[slash+1:]
+
+	var lists [5 + maxPrefixKey][]int
+	lists[0] = idx.basenames[base]
+	if dot := strings.LastIndexByte(base, '.'); dot >= 0 {
+		lists[1] = idx.extensions[base[dot+1:]]
+	}
+	if slash >= 0 {
+		dir := key[:slash]
+		lists[2] = idx.parents[dir[strings.LastIndexByte(dir, '/')+1:]]
+	}
+	lists[3] = idx.prefixes[firstComponent(key)]
+	lists[4] = idx.others
+	for n := 1; n <= maxPrefixKey && n <= len(base); n++ {
+		if idx.namePrefixLens&(1<<uint(n)) != 0 {
+			lists[4+n] = idx.namePrefixes[base[:n]]
+		}
+	}
+
+	for {
+		// Pick the highest remaining index among the candidates.
+		best := -1
+		for l := range lists {
+			if n := len(lists[l]); n > 0 && (best < 0 || lists[l][n-1] > lists[best][len(lists[best])-1]) {
+				best = l
+			}
+		}
+		if best < 0 {
+			return nil
+		}
+		n := len(lists[best])
+		ip := patterns[lists[best][n-1]]
+		lists[best] = lists[best][:n-1]
+
+		if ip.matches(f, isDir) {
+			return ip
+		}
+	}
+}
+
+////////////////////////////////////////////////////////////
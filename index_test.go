@@ -0,0 +1,121 @@
+// Implement tests and benchmarks for the pattern index
+package ignore
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+////////////////////////////////////////////////////////////
+
+// Helper function which generates a large ignore file, with a mix of the kinds
+// of patterns found in generated ignore files.
+func generateIgnoreLines(n int) []string {
+	lines := make([]string, 0, n)
+	for i := 0; len(lines) < n; i++ {
+		lines = append(lines,
+			fmt.Sprintf("name%d", i),
+			fmt.Sprintf("*.ext%d", i),
+			fmt.Sprintf("/dir%d/**/*.tmp", i),
+			fmt.Sprintf("pkg%d/build/", i),
+			fmt.Sprintf("!keep%d.ext%d", i, i),
+			fmt.Sprintf("# section %d", i),
+			fmt.Sprintf("cache%d-*", i),
+			fmt.Sprintf("**/gen%d/*.go", i),
+		)
+	}
+	return lines[:n]
+}
+
+// Helper function which generates paths exercising `generateIgnoreLines`.
+func generatePaths(n int) []string {
+	paths := make([]string, 0, n)
+	for i := 0; len(paths) < n; i++ {
+		paths = append(paths,
+			fmt.Sprintf("src/name%d", i),
+			fmt.Sprintf("src/file.ext%d", i),
+			fmt.Sprintf("src/keep%d.ext%d", i, i),
+			fmt.Sprintf("dir%d/a/b/c.tmp", i),
+			fmt.Sprintf("pkg%d/build/out.bin", i),
+			fmt.Sprintf("lib/cache%d-data", i),
+			fmt.Sprintf("x/y/gen%d/z.go", i),
+			fmt.Sprintf("src/module%d/main.go", i),
+		)
+	}
+	return paths[:n]
+}
+
+////////////////////////////////////////////////////////////
+
+// Validate that the index gives the same answers as a full scan
+func TestPatternIndex_AgreesWithLinearScan(t *testing.T) {
+	lines := append(generateIgnoreLines(400), "*", "!*.go", "!*/", "/src/name3", "src/**/*.ext2", ".hidden/",
+		"**/lib/*/cache*", "!**/gen3/keep.go", "**/**/name5", "ca?he7-*")
+	indexed := CompileIgnoreLines(lines...)
+	linear := CompileIgnoreLines(lines...)
+	linear.index = nil
+
+	paths := append(generatePaths(400), "src/main.go", "a/.hidden", "deep/.hidden/x", "src/a/b/x.ext2",
+		"lib/a/cache", "x/lib/a/b/cache", "gen3/keep.go", "a/gen3/keep.go", "a/b/name5", "cathe7-x")
+	for _, f := range paths {
+		for _, isDir := range []bool{false, true} {
+			expectMatch, expectHow := linear.MatchesPathIsDirHow(f, isDir)
+			match, how := indexed.MatchesPathIsDirHow(f, isDir)
+			assert.Equal(t, expectMatch, match, "%s (dir: %v) should have the same verdict", f, isDir)
+			assert.Equal(t, expectHow, how, "%s (dir: %v) should have the same pattern", f, isDir)
+		}
+	}
+}
+
+func TestPatternIndex_Classification(t *testing.T) {
+	object := CompileIgnoreLines("node_modules", "*.tar.gz", "/build/**", "docs/*.html", "*.[oa]", "**/tmp", "a?c",
+		"cache-*", "**/gen/*.go", "**/**/*.log", "**/src/*/*.pb.go", "/**/*.tmp", "**/a/**/b", "generated-files-*")
+	idx := object.index
+
+	assert.Equal(t, []int{0}, idx.basenames["node_modules"], "node_modules should be a basename")
+	assert.Equal(t, []int{1}, idx.extensions["gz"], "*.tar.gz should be an extension")
+	assert.Equal(t, []int{2}, idx.prefixes["build"], "/build/** should be a prefix")
+	assert.Equal(t, []int{3}, idx.prefixes["docs"], "docs/*.html should be a prefix")
+	assert.Equal(t, []int{5}, idx.basenames["tmp"], "**/tmp should be a basename")
+	assert.Equal(t, []int{6}, idx.namePrefixes["a"], "a?c should be a name prefix")
+	assert.Equal(t, []int{7}, idx.namePrefixes["cache-"], "cache-* should be a name prefix")
+	assert.Equal(t, []int{8}, idx.parents["gen"], "**/gen/*.go should be keyed by its parent")
+	assert.Equal(t, []int{9}, idx.extensions["log"], "**/**/*.log should be an extension")
+	assert.Equal(t, []int{10}, idx.extensions["go"], "**/src/*/*.pb.go should be an extension")
+	assert.Equal(t, []int{13}, idx.namePrefixes["generate"], "long name prefixes should be truncated")
+	assert.Equal(t, []int{11}, idx.extensions["tmp"], "/**/*.tmp should be an extension")
+	assert.Equal(t, []int{4, 12}, idx.others, "the rest should be tried for every path")
+
+	assert.Equal(t, true, object.MatchesPath("src/a.tar.gz"), "src/a.tar.gz should match")
+	assert.Equal(t, false, object.MatchesPath("src/a.gz"), "src/a.gz should not match")
+	assert.Equal(t, true, object.MatchesPath("docs/index.html"), "docs/index.html should match")
+	assert.Equal(t, false, object.MatchesPath("src/docs/index.html"), "src/docs/index.html should not match")
+	assert.Equal(t, true, object.MatchesPath("a/cache-data"), "a/cache-data should match")
+	assert.Equal(t, false, object.MatchesPath("a/cach"), "a/cach should not match")
+	assert.Equal(t, true, object.MatchesPath("gen/main.go"), "gen/main.go should match")
+	assert.Equal(t, true, object.MatchesPath("x/y/gen/main.go"), "x/y/gen/main.go should match")
+	assert.Equal(t, false, object.MatchesPath("gen/x/main.go"), "gen/x/main.go should not match")
+	assert.Equal(t, true, object.MatchesPath("generated-files-1"), "generated-files-1 should match")
+	assert.Equal(t, false, object.MatchesPath("generated-file"), "generated-file should not match")
+}
+
+////////////////////////////////////////////////////////////
+
+func benchmarkMatchesPath(b *testing.B, indexed bool) {
+	object := CompileIgnoreLines(generateIgnoreLines(2000)...)
+	if !indexed {
+		object.index = nil
+	}
+	paths := generatePaths(1000)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		object.MatchesPath(paths[i%len(paths)])
+	}
+}
+
+func BenchmarkMatchesPath_Indexed(b *testing.B) { benchmarkMatchesPath(b, true) }
+func BenchmarkMatchesPath_Linear(b *testing.B)  { benchmarkMatchesPath(b, false) }
@@ -0,0 +1,338 @@
+package ignore
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+////////////////////////////////////////////////////////////
+
+// Severity tells how serious a Diagnostic is.
+type Severity int
+
+const (
+	// SeverityInfo is for style issues which do not change what is matched.
+	SeverityInfo Severity = iota
+
+	// SeverityWarning is for lines which most likely do not do what their
+	// author meant.
+	SeverityWarning
+
+	// SeverityError is for lines which are invalid, or can never match.
+	SeverityError
+)
+
+// String implements fmt.Stringer.
+func (s Severity) String() string {
+	switch s {
+	case SeverityInfo:
+		return "info"
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	}
+	return "unknown"
+}
+
+// Names of the checks run by Lint, as reported in Diagnostic.Check.
+const (
+	CheckInvalid             = "invalid-pattern"
+	CheckNeverMatches        = "never-matches"
+	CheckIneffectiveNegation = "ineffective-negation"
+	CheckDuplicate           = "duplicate"
+	CheckShadowed            = "shadowed"
+	CheckDoubleStar          = "invalid-double-star"
+	CheckTrailingWhitespace  = "trailing-whitespace"
+	CheckCRLF                = "crlf"
+)
+
+// Diagnostic is an issue found in a line of an ignore file by Lint.
+type Diagnostic struct {
+	Source   string // Path of the ignore file, empty for lines passed directly
+	LineNo   int    // 1-based line number within the source
+	Line     string // The line as it was read
+	Severity Severity
+	Check    string // The name of the check which found the issue
+	Message  string
+	Fix      string // A suggested fix, if there is one
+}
+
+// String formats the diagnostic as "source:line: severity: message [check]".
+func (d *Diagnostic) String() string {
+	where := fmt.Sprintf("line %d", d.LineNo)
+	if d.Source != "" {
+		where = fmt.Sprintf("%s:%d", d.Source, d.LineNo)
+	}
+	return fmt.Sprintf("%s: %s: %s [%s]", where, d.Severity, d.Message, d.Check)
+}
+
+////////////////////////////////////////////////////////////
+
+// LintLines is like Lint for the GitIgnore compiled from `lines`.
+func LintLines(lines ...string) []*Diagnostic {
+	return Lint(CompileIgnoreLines(lines...))
+}
+
+// LintFile is like Lint for the GitIgnore compiled from the file at `fpath`.
+func LintFile(fpath string) ([]*Diagnostic, error) {
+	gi, err := CompileIgnoreFile(fpath)
+	if err != nil {
+		return nil, err
+	}
+	return Lint(gi), nil
+}
+
+// Lint analyzes the patterns of a GitIgnore, and reports the lines which are
+// invalid, which can never match or never have an effect, and the ones whose
+// formatting is likely a mistake. The diagnostics are sorted by source and
+// line number.
+func Lint(gi *GitIgnore) []*Diagnostic {
+	l := &linter{gi: gi}
+	for _, le := range gi.warnings {
+		l.report(le.Source, le.LineNo, le.Line, SeverityError, CheckInvalid,
+			fmt.Sprintf("invalid pattern: %v", le.Err), "fix or remove the line")
+	}
+
+	crlf := map[string]bool{}
+	for i, ip := range gi.patterns {
+		if gi.dialect != DialectGit {
+			// The other checks rely on the rules of git.
+			l.checkDuplicate(i)
+			continue
+		}
+		l.checkNeverMatches(ip)
+		l.checkDoubleStar(ip)
+		l.checkTrailingWhitespace(ip)
+		if !crlf[ip.Source] && strings.HasSuffix(ip.Line, "\r") {
+			crlf[ip.Source] = true
+			l.reportPattern(ip, SeverityInfo, CheckCRLF,
+				"the file has CRLF line endings", "convert the file to LF line endings")
+		}
+
+		if l.checkDuplicate(i) {
+			continue
+		}
+		l.checkShadowed(i)
+		l.checkIneffectiveNegation(i)
+	}
+
+	sort.SliceStable(l.diagnostics, func(i, j int) bool {
+		a, b := l.diagnostics[i], l.diagnostics[j]
+		if a.Source != b.Source {
+			return a.Source < b.Source
+		}
+		return a.LineNo < b.LineNo
+	})
+	return l.diagnostics
+}
+
+// linter holds the state of Lint.
+type linter struct {
+	gi          *GitIgnore
+	diagnostics []*Diagnostic
+}
+
+func (l *linter) report(source string, lineNo int, line string, severity Severity, check, message, fix string) {
+	l.diagnostics = append(l.diagnostics, &Diagnostic{
+		Source:   source,
+		LineNo:   lineNo,
+		Line:     line,
+		Severity: severity,
+		Check:    check,
+		Message:  message,
+		Fix:      fix,
+	})
+}
+
+func (l *linter) reportPattern(ip *IgnorePattern, severity Severity, check, message, fix string) {
+	l.report(ip.Source, ip.LineNo, ip.Line, severity, check, message, fix)
+}
+
+// where returns how to refer to the line of `ip` from the line of `from`.
+func where(ip, from *IgnorePattern) string {
+	if ip.Source == from.Source {
+		return fmt.Sprintf("line %d", ip.LineNo)
+	}
+	return fmt.Sprintf("%s:%d", ip.Source, ip.LineNo)
+}
+
+////////////////////////////////////////////////////////////
+
+// checkNeverMatches reports the patterns with a path component which never
+// appears in the paths git looks at: an empty one, "." or "..".
+func (l *linter) checkNeverMatches(ip *IgnorePattern) {
+	if !ip.anchored {
+		if ip.glob == "." || ip.glob == ".." {
+			l.reportPattern(ip, SeverityError, CheckNeverMatches,
+				fmt.Sprintf("%q never appears as a path component", ip.glob), "remove the line")
+		}
+		return
+	}
+	for _, component := range strings.Split(ip.glob, "/") {
+		switch component {
+		case "":
+			l.reportPattern(ip, SeverityError, CheckNeverMatches,
+				"paths never contain consecutive slashes", "replace the consecutive slashes with a single one")
+			return
+		case ".", "..":
+			l.reportPattern(ip, SeverityError, CheckNeverMatches,
+				fmt.Sprintf("%q never appears as a path component", component),
+				"write the path relative to the directory of the ignore file")
+			return
+		}
+	}
+}
+
+// checkDoubleStar reports the "**" which are not a whole path component, and
+// so are the same as a single "*" [Rule 9.iv].
+func (l *linter) checkDoubleStar(ip *IgnorePattern) {
+	glob := ip.glob
+	for i := 0; i < len(glob); i++ {
+		switch glob[i] {
+		case '\\':
+			i++
+			continue
+		case '[':
+			if _, end, err := parseBracket(glob, i); err == nil {
+				i = end - 1
+			}
+			continue
+		case '*':
+		default:
+			continue
+		}
+
+		start := i
+		for i+1 < len(glob) && glob[i+1] == '*' {
+			i++
+		}
+		if i == start {
+			continue
+		}
+		before := start == 0 || glob[start-1] == '/'
+		after := i == len(glob)-1 || glob[i+1] == '/'
+		if !before || !after {
+			l.reportPattern(ip, SeverityWarning, CheckDoubleStar,
+				`"**" only matches across directories as a whole path component, here it is the same as "*"`,
+				`use "*", or separate "**" from the rest of the pattern with slashes`)
+			return
+		}
+	}
+}
+
+// checkTrailingWhitespace reports the lines whose trailing spaces were dropped
+// because they are not escaped [Rule 3].
+func (l *linter) checkTrailingWhitespace(ip *IgnorePattern) {
+	if strings.TrimSuffix(ip.Stripped, "\r") == "" {
+		return
+	}
+	l.reportPattern(ip, SeverityWarning, CheckTrailingWhitespace,
+		"trailing spaces are ignored unless they are escaped with a backslash",
+		`remove the trailing spaces, or escape them as "\ "`)
+}
+
+// checkDuplicate reports the pattern `i` if an earlier one is the same.
+func (l *linter) checkDuplicate(i int) bool {
+	ip := l.gi.patterns[i]
+	for _, other := range l.gi.patterns[:i] {
+		if samePattern(ip, other) {
+			l.reportPattern(ip, SeverityWarning, CheckDuplicate,
+				fmt.Sprintf("duplicate of %s", where(other, ip)), "remove the line")
+			return true
+		}
+	}
+	return false
+}
+
+// checkShadowed reports the pattern `i` if a later one matches every path it
+// does, and so always decides instead of it.
+func (l *linter) checkShadowed(i int) {
+	ip := l.gi.patterns[i]
+	for _, later := range l.gi.patterns[i+1:] {
+		if samePattern(ip, later) || !covers(later, ip) {
+			continue
+		}
+		how := "overridden"
+		if later.Negate == ip.Negate {
+			how = "made redundant"
+		}
+		l.reportPattern(ip, SeverityWarning, CheckShadowed,
+			fmt.Sprintf("%s by %s, which matches every path this pattern does", how, where(later, ip)),
+			"remove the line, or move it after the other one")
+		return
+	}
+}
+
+// checkIneffectiveNegation reports the negated pattern `i` if a parent
+// directory of the paths it matches is excluded, since git never looks
+// inside of excluded directories [Rule 4].
+func (l *linter) checkIneffectiveNegation(i int) {
+	ip := l.gi.patterns[i]
+	if !ip.Negate || !ip.anchored {
+		return
+	}
+	for j := 0; j < len(ip.glob); j++ {
+		if ip.glob[j] != '/' {
+			continue
+		}
+		dir := ip.glob[:j]
+		if !isLiteral(dir) {
+			return
+		}
+		if parent := l.gi.lastMatch(dir, true); parent != nil && !parent.Negate {
+			l.reportPattern(ip, SeverityWarning, CheckIneffectiveNegation,
+				fmt.Sprintf("has no effect, the parent directory %q is excluded by %s", dir, where(parent, ip)),
+				contentsFix(parent, dir))
+			return
+		}
+	}
+}
+
+// contentsFix suggests to exclude the contents of the directory `dir` instead
+// of the directory itself, which the pattern `parent` excludes, so that the
+// paths inside of it can be re-included.
+func contentsFix(parent *IgnorePattern, dir string) string {
+	if parent.glob != dir {
+		return fmt.Sprintf("change %s so that it does not exclude %q itself, for example with \"/%s/*\"",
+			where(parent, parent), dir, dir)
+	}
+	line := strings.TrimSuffix(trimTrailingSpaces(strings.TrimSuffix(parent.Line, "\r")), "/")
+	contents := line + "/*"
+	if !parent.anchored {
+		contents = "**/" + contents
+	}
+	return fmt.Sprintf("replace %q with %q, so that the directory itself is not excluded", parent.Line, contents)
+}
+
+////////////////////////////////////////////////////////////
+
+// samePattern reports whether the patterns `a` and `b` are the same, even if
+// they are written differently.
+func samePattern(a, b *IgnorePattern) bool {
+	return a.Negate == b.Negate && a.glob == b.glob && a.anchored == b.anchored && a.dirOnly == b.dirOnly
+}
+
+// covers reports whether the pattern `q` is known to match every path which
+// the pattern `p` matches. It only recognizes the simple cases: a literal `p`
+// matched by `q`, and a `q` matching everything.
+func covers(q, p *IgnorePattern) bool {
+	if q.dirOnly && !p.dirOnly {
+		return false
+	}
+	if !q.anchored {
+		if q.glob == "*" || q.glob == "**" {
+			return true
+		}
+		// Every path matched by `p` has the same last component.
+		base := p.glob[strings.LastIndexByte(p.glob, '/')+1:]
+		return isLiteral(base) && wildmatch(q.glob, base, 0)
+	}
+	if q.glob == "**" {
+		return true
+	}
+	return p.anchored && isLiteral(p.glob) && wildmatch(q.glob, p.glob, wmPathname)
+}
+
+////////////////////////////////////////////////////////////
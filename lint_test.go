@@ -0,0 +1,111 @@
+// Implement tests for the linter
+package ignore
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+////////////////////////////////////////////////////////////
+
+func TestLint_Clean(t *testing.T) {
+	diagnostics := LintLines("# build output", "/build/", "*.o", "!keep.o", "**/tmp/**", "/docs/*", "!/docs/api/")
+	assert.Equal(t, 0, len(diagnostics), "there should be no diagnostics: %v", diagnostics)
+}
+
+func TestLint_Checks(t *testing.T) {
+	cases := []struct {
+		lines    []string
+		lineNo   int
+		check    string
+		severity Severity
+	}{
+		{[]string{"[z-a"}, 1, CheckInvalid, SeverityError},
+		{[]string{"foo//bar"}, 1, CheckNeverMatches, SeverityError},
+		{[]string{"./build"}, 1, CheckNeverMatches, SeverityError},
+		{[]string{"src/../build"}, 1, CheckNeverMatches, SeverityError},
+		{[]string{".."}, 1, CheckNeverMatches, SeverityError},
+		{[]string{"/docs/*", "!/docs/api/index.md"}, 2, CheckIneffectiveNegation, SeverityWarning},
+		{[]string{"build/", "!build/keep.txt"}, 2, CheckIneffectiveNegation, SeverityWarning},
+		{[]string{"*.o", "*.a", "*.o"}, 3, CheckDuplicate, SeverityWarning},
+		{[]string{"/build", "build/../x"}, 2, CheckNeverMatches, SeverityError},
+		{[]string{"main.o", "*.o"}, 1, CheckShadowed, SeverityWarning},
+		{[]string{"!keep.o", "*.o"}, 1, CheckShadowed, SeverityWarning},
+		{[]string{"/src/main.o", "*"}, 1, CheckShadowed, SeverityWarning},
+		{[]string{"/src/main.o", "/src/*.o"}, 1, CheckShadowed, SeverityWarning},
+		{[]string{"foo**"}, 1, CheckDoubleStar, SeverityWarning},
+		{[]string{"**foo"}, 1, CheckDoubleStar, SeverityWarning},
+		{[]string{"a/**b/c"}, 1, CheckDoubleStar, SeverityWarning},
+		{[]string{"*.o  "}, 1, CheckTrailingWhitespace, SeverityWarning},
+		{[]string{"*.o\r", "*.a\r"}, 1, CheckCRLF, SeverityInfo},
+	}
+	for _, c := range cases {
+		diagnostics := LintLines(c.lines...)
+		if assert.Equal(t, 1, len(diagnostics), "%q should have one diagnostic: %v", c.lines, diagnostics) {
+			d := diagnostics[0]
+			assert.Equal(t, c.lineNo, d.LineNo, "%q should be reported on line %d", c.lines, c.lineNo)
+			assert.Equal(t, c.check, d.Check, "%q should be reported by %s", c.lines, c.check)
+			assert.Equal(t, c.severity, d.Severity, "%q should be reported as %v", c.lines, c.severity)
+			assert.NotEqual(t, "", d.Fix, "%q should have a suggested fix", c.lines)
+		}
+	}
+}
+
+// Validate that an ineffective negation suggests to exclude the contents of
+// the parent directory instead of the directory itself
+func TestLint_IneffectiveNegationFix(t *testing.T) {
+	cases := []struct {
+		lines []string
+		fix   string
+		fixed []string
+		path  string
+	}{
+		{[]string{"/build/", "!/build/keep.txt"}, `replace "/build/" with "/build/*", so that the directory itself is not excluded`,
+			[]string{"/build/*", "!/build/keep.txt"}, "build/keep.txt"},
+		{[]string{"build/", "!build/keep.txt"}, `replace "build/" with "**/build/*", so that the directory itself is not excluded`,
+			[]string{"**/build/*", "!build/keep.txt"}, "build/keep.txt"},
+		{[]string{"/docs/*", "!/docs/api/index.md"}, `change line 1 so that it does not exclude "docs/api" itself, for example with "/docs/api/*"`,
+			[]string{"/docs/api/*", "!/docs/api/index.md"}, "docs/api/index.md"},
+	}
+	for _, c := range cases {
+		diagnostics := LintLines(c.lines...)
+		if assert.Equal(t, 1, len(diagnostics), "%q should have one diagnostic", c.lines) {
+			assert.Equal(t, c.fix, diagnostics[0].Fix, "%q should have the suggested fix", c.lines)
+		}
+		assert.Equal(t, 0, len(LintLines(c.fixed...)), "%q should have no diagnostic", c.fixed)
+		assert.Equal(t, false, CompileIgnoreLines(c.fixed...).MatchesPath(c.path), "%s should be re-included", c.path)
+	}
+}
+
+func TestLint_NotShadowed(t *testing.T) {
+	for _, lines := range [][]string{
+		{"main.o", "*.a"},
+		{"main.o", "/*.o"},
+		{"main.o", "*/"},
+		{"/src/*.o", "*.o"},
+		{"/src/main.o", "/lib/*.o"},
+		{"*.o", "!*.o", "foo"},
+		{"a/**/b", "**/c/**"},
+	} {
+		for _, d := range LintLines(lines...) {
+			assert.NotEqual(t, CheckShadowed, d.Check, "%q should not be shadowed: %v", lines, d)
+		}
+	}
+}
+
+func TestLintFile(t *testing.T) {
+	writeFileToTestDir("test.gitignore", "*.o\n*.o\n[z-a\n")
+	defer cleanupTestDir()
+
+	diagnostics, err := LintFile("./test_fixtures/test.gitignore")
+	assert.Nil(t, err, "err should be nil")
+	if assert.Equal(t, 2, len(diagnostics), "there should be two diagnostics") {
+		assert.Equal(t, "./test_fixtures/test.gitignore:2: warning: duplicate of line 1 [duplicate]", diagnostics[0].String())
+		assert.Equal(t, 3, diagnostics[1].LineNo, "the invalid line should be reported")
+	}
+
+	_, err = LintFile("./test_fixtures/invalid.file")
+	assert.True(t, os.IsNotExist(err), "err should be unknown file")
+}
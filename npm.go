@@ -0,0 +1,261 @@
+package ignore
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strings"
+)
+
+////////////////////////////////////////////////////////////
+
+const (
+	// NpmIgnoreFile is the name of the per-directory ignore file of npm,
+	// used instead of the ".gitignore" file of the same directory.
+	NpmIgnoreFile = ".npmignore"
+
+	packageJSON = "package.json"
+)
+
+// npmDefaultExcludes holds the paths which npm never puts in a tarball,
+// whatever the ignore files and the "files" field say.
+var npmDefaultExcludes = CompileIgnoreLinesFrom("npm-defaults",
+	NpmIgnoreFile,
+	GitIgnoreFile,
+	".git",
+	".svn",
+	".hg",
+	"CVS",
+	"/.lock-wscript",
+	"/.wafpickle-*",
+	"/build/config.gypi",
+	"npm-debug.log",
+	".npmrc",
+	".*.swp",
+	".DS_Store",
+	"._*",
+	"*.orig",
+	"/package-lock.json",
+	"/yarn.lock",
+	"/pnpm-lock.yaml",
+	"/archived-packages/",
+	"/node_modules/",
+)
+
+// packageManifest holds the fields of a "package.json" file which select the
+// files of the package.
+type packageManifest struct {
+	Files []string        `json:"files"`
+	Main  string          `json:"main"`
+	Bin   json.RawMessage `json:"bin"`
+}
+
+////////////////////////////////////////////////////////////
+
+// Packlist returns the files which `npm pack` puts in the tarball of the
+// package in the directory `root`, as sorted slash separated paths relative to
+// it. It fails if there is no "package.json" file in `root`.
+//
+// The files are selected like npm does:
+//
+//  1. "package.json", the README and LICENSE (or LICENCE) files of any case
+//     and extension, and the files of the "main" and "bin" fields are always
+//     included.
+//  2. Version control directories, the "node_modules" directory of the root,
+//     lock files and editor leftovers are always excluded.
+//  3. In each directory, the ".npmignore" file excludes files like a
+//     ".gitignore" file, which is used instead when there is no
+//     ".npmignore". Deeper files take precedence, as in a Repository.
+//  4. If the "files" field is set, only the paths it lists are included, along
+//     with the contents of the directories it lists. The ignore files of the
+//     root directory are then not used, but the ones below it still are.
+//
+// Bundled dependencies are not supported, the "node_modules" directory of the
+// root is always excluded. The ones in subdirectories are packed like any
+// other directory.
+func Packlist(root string) ([]string, error) {
+	return PacklistFS(os.DirFS(root), ".")
+}
+
+// PacklistFS is like Packlist, for the package in the directory `root` of
+// `fsys`.
+func PacklistFS(fsys fs.FS, root string) ([]string, error) {
+	p, err := newPacklist(fsys, root)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	err = fs.WalkDir(fsys, root, func(fpath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel := fsRel(root, fpath)
+		if d.IsDir() {
+			if rel != "" && npmDefaultExcludes.Match(rel, true).Ignored() {
+				return fs.SkipDir
+			}
+			return p.discover(fpath, rel)
+		}
+		if p.included(rel) {
+			files = append(files, rel)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+////////////////////////////////////////////////////////////
+
+// packlist holds the state of PacklistFS.
+type packlist struct {
+	fsys fs.FS
+
+	// repo holds the ".npmignore" or ".gitignore" file of each directory.
+	repo *Repository
+
+	// files holds the "files" field of the manifest as patterns anchored to
+	// the root, or nil if it is not set.
+	files *GitIgnore
+
+	// required holds the files of the "main" and "bin" fields.
+	required map[string]bool
+}
+
+// newPacklist reads the "package.json" file in the directory `root`.
+func newPacklist(fsys fs.FS, root string) (*packlist, error) {
+	bs, err := fs.ReadFile(fsys, path.Join(root, packageJSON))
+	if err != nil {
+		return nil, err
+	}
+	var manifest packageManifest
+	if err := json.Unmarshal(bs, &manifest); err != nil {
+		return nil, fmt.Errorf("%s: %v", packageJSON, err)
+	}
+
+	p := &packlist{
+		fsys:     fsys,
+		repo:     newRepository("", GitTiers),
+		required: map[string]bool{},
+	}
+	if manifest.Main != "" {
+		p.required[cleanManifestPath(manifest.Main)] = true
+	}
+	bins, err := manifestBins(manifest.Bin)
+	if err != nil {
+		return nil, err
+	}
+	for _, bin := range bins {
+		p.required[cleanManifestPath(bin)] = true
+	}
+
+	if manifest.Files != nil {
+		lines := make([]string, 0, len(manifest.Files))
+		for _, f := range manifest.Files {
+			negate := strings.HasPrefix(f, "!")
+			f = "/" + cleanManifestPath(strings.TrimPrefix(f, "!"))
+			if negate {
+				f = "!" + f
+			}
+			lines = append(lines, f)
+		}
+		p.files = CompileIgnoreLinesFrom(packageJSON, lines...)
+	}
+	return p, nil
+}
+
+// manifestBins returns the paths of the "bin" field, which is either a path
+// or an object mapping command names to paths.
+func manifestBins(raw json.RawMessage) ([]string, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	var bin string
+	if err := json.Unmarshal(raw, &bin); err == nil {
+		return []string{bin}, nil
+	}
+	var bins map[string]string
+	if err := json.Unmarshal(raw, &bins); err != nil {
+		return nil, fmt.Errorf("%s: invalid bin field: %v", packageJSON, err)
+	}
+	paths := make([]string, 0, len(bins))
+	for _, bin := range bins {
+		paths = append(paths, bin)
+	}
+	return paths, nil
+}
+
+// cleanManifestPath converts a path from "package.json" into the slash
+// separated form used in the packlist.
+func cleanManifestPath(f string) string {
+	f = path.Clean(strings.Replace(f, "\\", "/", -1))
+	return strings.TrimPrefix(strings.TrimPrefix(f, "./"), "/")
+}
+
+// discover compiles the ignore file of the directory `fpath`, whose path
+// relative to the root of the package is `dir`. The ".npmignore" file is
+// preferred over the ".gitignore" one.
+func (p *packlist) discover(fpath, dir string) error {
+	if dir == "" && p.files != nil {
+		return nil
+	}
+	for _, name := range []string{NpmIgnoreFile, GitIgnoreFile} {
+		gi, err := CompileIgnoreFS(p.fsys, path.Join(fpath, name))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return err
+		}
+		p.repo.add(p.repo.tiers[0], dir, gi)
+		return nil
+	}
+	return nil
+}
+
+// included reports whether the file at the slash separated path `f`, relative
+// to the root of the package, is put in the tarball.
+func (p *packlist) included(f string) bool {
+	if p.required[f] || isAlwaysIncluded(f) {
+		return true
+	}
+	if npmDefaultExcludes.Match(f, false).Ignored() || p.repo.Match(f, false).Ignored() {
+		return false
+	}
+	if p.files == nil {
+		return true
+	}
+
+	// The deepest of `f` and its parents listed in "files" decides.
+	for i := len(f); i > 0; i = strings.LastIndexByte(f[:i], '/') {
+		if ip := p.files.lastMatch(f[:i], i < len(f)); ip != nil {
+			return !ip.Negate
+		}
+	}
+	return false
+}
+
+// isAlwaysIncluded reports whether `f` is one of the files of the root of the
+// package which npm always includes.
+func isAlwaysIncluded(f string) bool {
+	if f == packageJSON {
+		return true
+	}
+	if strings.IndexByte(f, '/') >= 0 {
+		return false
+	}
+	name := strings.ToLower(f)
+	if dot := strings.IndexByte(name, '.'); dot >= 0 {
+		name = name[:dot]
+	}
+	return name == "readme" || name == "license" || name == "licence"
+}
+
+////////////////////////////////////////////////////////////
@@ -0,0 +1,143 @@
+// Implement tests for `Packlist` and `PacklistFS`
+package ignore
+
+import (
+	"os"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+////////////////////////////////////////////////////////////
+
+// Helper function which builds an fstest.MapFS from file contents.
+func mapFS(files map[string]string) fstest.MapFS {
+	fsys := fstest.MapFS{}
+	for name, content := range files {
+		fsys[name] = &fstest.MapFile{Data: []byte(content)}
+	}
+	return fsys
+}
+
+////////////////////////////////////////////////////////////
+
+// Validate the selection without a "files" field, where the ignore files
+// decide, with ".npmignore" taking the place of ".gitignore".
+func TestPacklist_IgnoreFiles(t *testing.T) {
+	fsys := mapFS(map[string]string{
+		"package.json":              `{"name": "pkg", "version": "1.0.0"}`,
+		"README.md":                 "",
+		"index.js":                  "",
+		"debug.log":                 "",
+		"coverage/lcov.info":        "",
+		".gitignore":                "*.log\ncoverage/\ndist/\n",
+		".npmignore":                "coverage/\ntest/\n",
+		"dist/index.min.js":         "",
+		"test/index.test.js":        "",
+		"lib/a.js":                  "",
+		"lib/a.js.map":              "",
+		"lib/.gitignore":            "*.map\n",
+		"lib/vendor/b.js":           "",
+		"lib/vendor/.npmignore":     "!*.map\n",
+		"lib/vendor/b.js.map":       "",
+		"node_modules/dep/index.js": "",
+		"src/node_modules/x.js":     "",
+		".git/HEAD":                 "",
+		".DS_Store":                 "",
+		".npmrc":                    "",
+		"package-lock.json":         "",
+		"index.js.orig":             "",
+	})
+
+	files, err := PacklistFS(fsys, ".")
+	assert.Nil(t, err, "err should be nil")
+	assert.Equal(t, []string{
+		"README.md",
+		"debug.log",
+		"dist/index.min.js",
+		"index.js",
+		"lib/a.js",
+		"lib/vendor/b.js",
+		"lib/vendor/b.js.map",
+		"package.json",
+		"src/node_modules/x.js",
+	}, files)
+}
+
+// Validate the selection with a "files" field, which the ignore files below
+// the root still apply to.
+func TestPacklist_FilesField(t *testing.T) {
+	fsys := mapFS(map[string]string{
+		"pkg/package.json": `{
+			"name": "pkg",
+			"main": "./index.js",
+			"bin": {"tool": "scripts/tool.js"},
+			"files": ["lib/", "types/*.d.ts", "!lib/fixtures"]
+		}`,
+		"pkg/readme":                 "",
+		"pkg/LICENCE.txt":            "",
+		"pkg/CHANGELOG.md":           "",
+		"pkg/.npmignore":             "lib\n",
+		"pkg/index.js":               "",
+		"pkg/scripts/tool.js":        "",
+		"pkg/scripts/build.js":       "",
+		"pkg/lib/a.js":               "",
+		"pkg/lib/a.test.js":          "",
+		"pkg/lib/.npmignore":         "*.test.js\n",
+		"pkg/lib/deep/b.js":          "",
+		"pkg/lib/fixtures/data.json": "",
+		"pkg/types/index.d.ts":       "",
+		"pkg/types/index.ts":         "",
+		"pkg/types/sub/other.d.ts":   "",
+	})
+
+	files, err := PacklistFS(fsys, "pkg")
+	assert.Nil(t, err, "err should be nil")
+	assert.Equal(t, []string{
+		"LICENCE.txt",
+		"index.js",
+		"lib/a.js",
+		"lib/deep/b.js",
+		"package.json",
+		"readme",
+		"scripts/tool.js",
+		"types/index.d.ts",
+	}, files)
+
+	files, err = PacklistFS(mapFS(map[string]string{
+		"package.json": `{"files": [], "bin": "cli.js"}`,
+		"cli.js":       "",
+		"index.js":     "",
+	}), ".")
+	assert.Nil(t, err, "err should be nil")
+	assert.Equal(t, []string{"cli.js", "package.json"}, files)
+}
+
+func TestPacklist_InvalidManifest(t *testing.T) {
+	_, err := PacklistFS(mapFS(map[string]string{"index.js": ""}), ".")
+	assert.NotNil(t, err, "err should be a missing package.json")
+
+	_, err = PacklistFS(mapFS(map[string]string{"package.json": "{"}), ".")
+	assert.NotNil(t, err, "err should be invalid JSON")
+
+	_, err = PacklistFS(mapFS(map[string]string{"package.json": `{"bin": 42}`}), ".")
+	assert.NotNil(t, err, "err should be an invalid bin field")
+}
+
+// Validate Packlist on the OS file system
+func TestPacklist_OS(t *testing.T) {
+	root := writeTestTree(t, map[string]string{
+		"package.json": `{"files": ["dist"]}`,
+		"dist/x.js":    "",
+		"src/x.ts":     "",
+		".gitignore":   "dist/\n",
+	})
+	defer os.RemoveAll(root)
+
+	files, err := Packlist(root)
+	assert.Nil(t, err, "err should be nil")
+	assert.Equal(t, []string{"dist/x.js", "package.json"}, files)
+}
+
+////////////////////////////////////////////////////////////
@@ -0,0 +1,243 @@
+package ignore
+
+import (
+	"fmt"
+	"io/fs"
+	"io/ioutil"
+	"strings"
+)
+
+////////////////////////////////////////////////////////////
+
+// Dialect selects the syntax and the matching rules of the ignore files.
+type Dialect int
+
+const (
+	// DialectGit is the syntax of ".gitignore" files, see the package
+	// documentation.
+	DialectGit Dialect = iota
+
+	// DialectDocker is the syntax of ".dockerignore" files. The patterns
+	// are cleaned with filepath.Clean and always relative to the root, they
+	// match the paths inside of the directories they match, and the last
+	// matching one decides, even if a parent directory is excluded. "**"
+	// matches any number of directories, and comments must start the line.
+	DialectDocker
+
+	// DialectMercurial is the syntax of ".hgignore" files. The patterns are
+	// regexps, unless a "syntax: glob" or "syntax: rootglob" line says
+	// otherwise, and a "re:", "glob:" or "rootglob:" prefix overrides it for
+	// a single pattern. Regexps and globs match at any depth, rootglobs only
+	// from the root, and they all match the contents of the directories they
+	// match. There is no negation.
+	DialectMercurial
+)
+
+// String implements fmt.Stringer.
+func (d Dialect) String() string {
+	switch d {
+	case DialectGit:
+		return "git"
+	case DialectDocker:
+		return "docker"
+	case DialectMercurial:
+		return "mercurial"
+	}
+	return fmt.Sprintf("Dialect(%d)", int(d))
+}
+
+// MatchMode tells whether a GitIgnore is built to match as fast as possible,
+// or to tell as much as possible about its patterns.
+type MatchMode int
+
+const (
+	// MatchForAttribution builds every pattern with its Pattern regex, in
+	// addition to its Source, Line and LineNo. It is the default.
+	MatchForAttribution MatchMode = iota
+
+	// MatchForSpeed skips compiling the Pattern regex of the patterns,
+	// which is the most expensive part of compiling a large ignore file.
+	// The Pattern of the patterns is nil, matching is not affected.
+	MatchForSpeed
+)
+
+////////////////////////////////////////////////////////////
+
+// Option configures Compile.
+type Option func(*compileOptions)
+
+// compileOptions holds the configuration built by the options of Compile.
+type compileOptions struct {
+	inputs     []func(gi *GitIgnore) error
+	ignoreCase bool
+	base       string
+	strict     bool
+	separator  byte
+	dialect    Dialect
+	mode       MatchMode
+	includes   bool
+}
+
+// WithLines adds `lines` to the patterns, like CompileIgnoreLines.
+func WithLines(lines ...string) Option {
+	return WithLinesFrom("", lines...)
+}
+
+// WithLinesFrom adds `lines` to the patterns, and attributes them to
+// `source`, like CompileIgnoreLinesFrom.
+func WithLinesFrom(source string, lines ...string) Option {
+	return func(o *compileOptions) {
+		o.inputs = append(o.inputs, func(gi *GitIgnore) error {
+			gi.compileLines(source, lines)
+			return nil
+		})
+	}
+}
+
+// WithFile adds the lines of the ignore file at `fpath` to the patterns.
+func WithFile(fpath string) Option {
+	return func(o *compileOptions) {
+		o.inputs = append(o.inputs, func(gi *GitIgnore) error {
+			bs, err := ioutil.ReadFile(fpath)
+			if err != nil {
+				return err
+			}
+			gi.compileLines(fpath, strings.Split(string(bs), "\n"))
+			return nil
+		})
+	}
+}
+
+// WithFS adds the lines of the ignore file `name` in `fsys` to the patterns.
+func WithFS(fsys fs.FS, name string) Option {
+	return func(o *compileOptions) {
+		o.inputs = append(o.inputs, func(gi *GitIgnore) error {
+			bs, err := fs.ReadFile(fsys, name)
+			if err != nil {
+				return err
+			}
+			gi.compileLinesIn(newIncluder(fsys, name), name, strings.Split(string(bs), "\n"))
+			return nil
+		})
+	}
+}
+
+// WithIgnoreCase makes the patterns match paths regardless of the case of
+// their ASCII letters, in literals, wildcards and bracket expressions alike,
+// as git does on checkouts with core.ignorecase set. The other dialects are
+// always case sensitive, and fail to compile with it.
+func WithIgnoreCase(ignoreCase bool) Option {
+	return func(o *compileOptions) {
+		o.ignoreCase = ignoreCase
+	}
+}
+
+// WithBase anchors the patterns to the directory `base`, as if the ignore file
+// was found in it. Matched paths are then relative to the directory which
+// contains `base`, like the root of a work tree, so that "/local.txt" with the
+// base "services/api" matches "services/api/local.txt". The paths outside of
+// `base` are never matched. The Base of the patterns is set to `base`.
+func WithBase(base string) Option {
+	return func(o *compileOptions) {
+		o.base = strings.Trim(strings.Replace(base, "\\", "/", -1), "/")
+	}
+}
+
+// WithStrict makes Compile fail with a *CompileError if any line is invalid,
+// like the Strict compile functions. By default invalid lines are skipped, and
+// reported by GitIgnore.Warnings.
+func WithStrict(strict bool) Option {
+	return func(o *compileOptions) {
+		o.strict = strict
+	}
+}
+
+// WithSeparator sets the path separator of the matched paths, which is
+// converted to a slash. By default, it is os.PathSeparator. Using '/' leaves
+// the paths as they are, even on Windows.
+func WithSeparator(separator byte) Option {
+	return func(o *compileOptions) {
+		o.separator = separator
+	}
+}
+
+// WithDialect selects the syntax of the lines, DialectGit by default.
+func WithDialect(dialect Dialect) Option {
+	return func(o *compileOptions) {
+		o.dialect = dialect
+	}
+}
+
+// WithIncludes makes the lines of the form "#!include:PATH" splice the lines of
+// the ignore file at PATH in their place, as in ".gcloudignore" files, instead
+// of being comments. PATH is relative to the directory of the file containing
+// the directive, or to the current directory for the lines given by WithLines,
+// and is read from the fs.FS of WithFS for the files given by it. The included
+// patterns are attributed to the included file. A missing file and an include
+// cycle make the directive invalid. It is only supported by DialectGit.
+func WithIncludes(includes bool) Option {
+	return func(o *compileOptions) {
+		o.includes = includes
+	}
+}
+
+// WithMatchMode selects what the GitIgnore is built for, MatchForAttribution
+// by default.
+func WithMatchMode(mode MatchMode) Option {
+	return func(o *compileOptions) {
+		o.mode = mode
+	}
+}
+
+////////////////////////////////////////////////////////////
+
+// Compile builds a GitIgnore from the inputs and settings given as options.
+// The inputs, given by WithLines, WithLinesFrom, WithFile and WithFS, are
+// compiled in order, so that later ones take precedence. For example:
+//
+//	gi, err := Compile(
+//		WithFile(".gitignore"),
+//		WithLinesFrom("cli-args", "*.tmp"),
+//		WithIgnoreCase(true),
+//	)
+func Compile(opts ...Option) (*GitIgnore, error) {
+	o := &compileOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	switch o.dialect {
+	case DialectGit:
+	case DialectDocker, DialectMercurial:
+		if o.ignoreCase {
+			return nil, fmt.Errorf("the %v dialect is case sensitive", o.dialect)
+		}
+		if o.includes {
+			return nil, fmt.Errorf("the %v dialect has no include directives", o.dialect)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported dialect: %v", o.dialect)
+	}
+
+	gi := &GitIgnore{
+		ignoreCase: o.ignoreCase,
+		base:       o.base,
+		separator:  o.separator,
+		noRegexp:   o.mode == MatchForSpeed,
+		dialect:    o.dialect,
+		includes:   o.includes,
+	}
+	for _, input := range o.inputs {
+		if err := input(gi); err != nil {
+			return nil, err
+		}
+	}
+	if gi.index == nil {
+		gi.index = newPatternIndex(gi.patterns, gi.ignoreCase)
+	}
+	if o.strict {
+		return gi.strict()
+	}
+	return gi, nil
+}
+
+////////////////////////////////////////////////////////////
@@ -0,0 +1,111 @@
+// Implement tests for `Compile` and its options
+package ignore
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+////////////////////////////////////////////////////////////
+
+func TestCompile_Inputs(t *testing.T) {
+	writeFileToTestDir("test.gitignore", "*.o\n!keep.o\n")
+	defer cleanupTestDir()
+	fsys := fstest.MapFS{"fs.ignore": {Data: []byte("*.fs\n")}}
+
+	object, err := Compile(
+		WithFile("./test_fixtures/test.gitignore"),
+		WithFS(fsys, "fs.ignore"),
+		WithLinesFrom("cli-args", "keep.o"),
+		WithLines("*.tmp"),
+	)
+	assert.Nil(t, err, "err should be nil")
+
+	_, how := object.MatchesPathHow("keep.o")
+	assert.Equal(t, "cli-args", how.Source, "keep.o should be matched by the later input")
+	_, how = object.MatchesPathHow("a.o")
+	assert.Equal(t, "./test_fixtures/test.gitignore", how.Source, "a.o should be matched by the file")
+	_, how = object.MatchesPathHow("a.fs")
+	assert.Equal(t, "fs.ignore", how.Source, "a.fs should be matched by the fs.FS")
+	_, how = object.MatchesPathHow("a.tmp")
+	assert.Equal(t, "", how.Source, "a.tmp should be matched by the lines")
+	assert.Equal(t, 1, how.LineNo, "each input should be numbered on its own")
+
+	_, err = Compile(WithLines("*.o"), WithFile("./test_fixtures/invalid.file"))
+	assert.NotNil(t, err, "err should be unknown file")
+}
+
+func TestCompile_IgnoreCase(t *testing.T) {
+	object, err := Compile(WithLines("*.LOG", "/Build/"), WithIgnoreCase(true))
+	assert.Nil(t, err, "err should be nil")
+	assert.Equal(t, true, object.MatchesPath("debug.log"), "debug.log should match")
+	assert.Equal(t, true, object.MatchesPath("build/a.o"), "build/a.o should match")
+
+	object, err = Compile(WithLines("*.o"), WithBase("Services/"), WithIgnoreCase(true))
+	assert.Nil(t, err, "err should be nil")
+	assert.Equal(t, true, object.MatchesPath("services/a.O"), "services/a.O should match")
+}
+
+func TestCompile_Base(t *testing.T) {
+	object, err := Compile(WithLines("/local.txt", "*.o"), WithBase("services/api/"))
+	assert.Nil(t, err, "err should be nil")
+	assert.Equal(t, true, object.MatchesPath("services/api/local.txt"), "services/api/local.txt should match")
+	assert.Equal(t, true, object.MatchesPath("services/api/pkg/a.o"), "services/api/pkg/a.o should match")
+	assert.Equal(t, false, object.MatchesPath("local.txt"), "local.txt should not match")
+	assert.Equal(t, false, object.MatchesPath("a.o"), "a.o should not match")
+	assert.Equal(t, false, object.MatchesPath("services/api"), "services/api should not match")
+	assert.Equal(t, false, object.MatchesPath("services/apix/a.o"), "services/apix/a.o should not match")
+
+	_, how := object.MatchesPathHow("services/api/a.o")
+	assert.Equal(t, "services/api", how.Base, "the patterns should have the base")
+}
+
+func TestCompile_Strict(t *testing.T) {
+	object, err := Compile(WithLines("*.o", "[z-a"))
+	assert.Nil(t, err, "err should be nil")
+	assert.Equal(t, 1, len(object.Warnings()), "the invalid line should be a warning")
+
+	object, err = Compile(WithLines("*.o", "[z-a"), WithStrict(true))
+	assert.Nil(t, object, "object should be nil")
+	assert.IsType(t, &CompileError{}, err, "err should be a *CompileError")
+}
+
+func TestCompile_Separator(t *testing.T) {
+	object, err := Compile(WithLines("/build/*.o"), WithSeparator('\\'))
+	assert.Nil(t, err, "err should be nil")
+	assert.Equal(t, true, object.MatchesPath(`build\a.o`), `build\a.o should match`)
+	assert.Equal(t, true, object.MatchesPath(`\build\a.o`), `\build\a.o should match`)
+
+	object, err = Compile(WithLines(`a\\b`), WithSeparator('/'))
+	assert.Nil(t, err, "err should be nil")
+	assert.Equal(t, true, object.MatchesPath(`a\b`), `a\b should be a single name`)
+}
+
+func TestCompile_Dialect(t *testing.T) {
+	_, err := Compile(WithLines("*.o"), WithDialect(DialectGit))
+	assert.Nil(t, err, "err should be nil")
+
+	_, err = Compile(WithLines("*.o"), WithDialect(Dialect(42)))
+	assert.NotNil(t, err, "err should be an unsupported dialect")
+	assert.Equal(t, "git", DialectGit.String())
+	assert.Equal(t, "docker", DialectDocker.String())
+
+	_, err = Compile(WithLines("*.o"), WithDialect(DialectDocker), WithIgnoreCase(true))
+	assert.NotNil(t, err, "err should be a case sensitive dialect")
+}
+
+func TestCompile_MatchMode(t *testing.T) {
+	object, err := Compile(WithLines("*.o", "!keep.o"), WithMatchMode(MatchForSpeed))
+	assert.Nil(t, err, "err should be nil")
+	matches, how := object.MatchesPathHow("a.o")
+	assert.Equal(t, true, matches, "a.o should match")
+	assert.Nil(t, how.Pattern, "the regex should not be compiled")
+	assert.Equal(t, false, object.MatchesPath("keep.o"), "keep.o should not match")
+
+	object, err = Compile(WithLines("*.o"))
+	assert.Nil(t, err, "err should be nil")
+	_, how = object.MatchesPathHow("a.o")
+	assert.NotNil(t, how.Pattern, "the regex should be compiled")
+}
@@ -0,0 +1,387 @@
+package ignore
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+////////////////////////////////////////////////////////////
+
+const (
+	// GitIgnoreFile is the name of the per-directory ignore file.
+	GitIgnoreFile = ".gitignore"
+
+	gitDir = ".git"
+)
+
+////////////////////////////////////////////////////////////
+
+// Repository holds every ignore file found under a work tree. Patterns in
+// each file are anchored to the directory containing the file, and files in
+// deeper directories take precedence over the ones above them.
+type Repository struct {
+	root string
+
+	// tiers holds the ignore files of each kind, from the highest
+	// precedence.
+	tiers []*repositoryTier
+
+	// gitRoots holds the slash separated directories, relative to the root
+	// of the work tree, which contain a ".git" entry. The root directory is
+	// represented by the empty string.
+	gitRoots map[string]bool
+
+	// inGit is set when a parent directory of the root contains a ".git"
+	// entry.
+	inGit bool
+
+	// excludes holds the ignore files from outside of the work tree, whose
+	// patterns are relative to its root. They are consulted after every file
+	// in `tiers`, in order.
+	excludes []*GitIgnore
+
+	// dirs maps the lower case form of every directory in `gitRoots` and in
+	// the `ignores` of the tiers to the directory itself, so that they are
+	// found regardless of case when `ignoreCase` is set.
+	dirs map[string]string
+
+	// ignoreCase is set when the paths are matched regardless of case, like
+	// git does with core.ignorecase.
+	ignoreCase bool
+}
+
+// repositoryTier holds the ignore files of an IgnoreTier.
+type repositoryTier struct {
+	IgnoreTier
+
+	// ignores maps a slash separated directory, relative to the root of the
+	// work tree, to the compiled ignore file found in it. The root directory
+	// is represented by the empty string.
+	ignores map[string]*GitIgnore
+}
+
+// newRepository returns a Repository for the work tree at `root`, without any
+// ignore file yet.
+func newRepository(root string, tiers []IgnoreTier) *Repository {
+	r := &Repository{
+		root:     root,
+		gitRoots: map[string]bool{},
+		dirs:     map[string]string{},
+	}
+	for _, t := range tiers {
+		r.tiers = append(r.tiers, &repositoryTier{IgnoreTier: t, ignores: map[string]*GitIgnore{}})
+	}
+	return r
+}
+
+// tier returns the tier whose ignore files are named `name`, or nil.
+func (r *Repository) tier(name string) *repositoryTier {
+	for _, t := range r.tiers {
+		if t.Name == name {
+			return t
+		}
+	}
+	return nil
+}
+
+// add records `gi` as the ignore file of the tier `t` in the slash separated
+// directory `dir`, relative to the root of the work tree.
+func (r *Repository) add(t *repositoryTier, dir string, gi *GitIgnore) {
+	gi.setBase(dir)
+	t.ignores[dir] = gi
+	r.dirs[strings.ToLower(dir)] = dir
+}
+
+// addGitRoot records that the slash separated directory `dir`, relative to
+// the root of the work tree, contains a ".git" entry.
+func (r *Repository) addGitRoot(dir string) {
+	r.gitRoots[dir] = true
+	r.dirs[strings.ToLower(dir)] = dir
+}
+
+// dirKey returns the form of the slash separated directory `dir` used as a key
+// in `gitRoots` and in the `ignores` of the tiers. When the Repository ignores
+// case, it is the directory recorded with the same lower case form, if any.
+func (r *Repository) dirKey(dir string) string {
+	if r.ignoreCase {
+		if d, ok := r.dirs[strings.ToLower(dir)]; ok {
+			return d
+		}
+	}
+	return dir
+}
+
+// CompileRepository walks the work tree rooted at `root`, and compiles every
+// ".gitignore" file found in it. Like git, it skips the ".git" directory and
+// the directories excluded by the ignore files found so far, whose own ignore
+// files are never read.
+func CompileRepository(root string) (*Repository, error) {
+	return CompileRepositoryTiers(root, GitTiers...)
+}
+
+// CompileRepositoryFS is like CompileRepository, but walks the tree rooted at
+// `root` in `fsys`.
+func CompileRepositoryFS(fsys fs.FS, root string) (*Repository, error) {
+	return CompileRepositoryTiersFS(fsys, root, GitTiers...)
+}
+
+// CompileRepositoryTiers is like CompileRepository, but compiles the ignore
+// files of every tier in `tiers`, which go from the highest precedence to the
+// lowest, as described in IgnoreTier. For example, to behave like ripgrep:
+//
+//	repo, err := CompileRepositoryTiers(root, RipgrepTiers...)
+func CompileRepositoryTiers(root string, tiers ...IgnoreTier) (*Repository, error) {
+	r := newRepository(root, tiers)
+	if requireGit(tiers) {
+		inGit, err := parentInGit(root)
+		if err != nil {
+			return nil, err
+		}
+		r.inGit = inGit
+	}
+
+	err := filepath.Walk(root, func(fpath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			return nil
+		}
+		if info.Name() == gitDir && fpath != root {
+			return filepath.SkipDir
+		}
+		rel, err := filepath.Rel(root, fpath)
+		if err != nil {
+			return err
+		}
+		dir := toSlashDir(rel)
+		if dir != "" && r.MatchesPathIsDir(dir, true) {
+			return filepath.SkipDir
+		}
+		return r.discover(dir, func(name string) (fs.FileInfo, error) {
+			return os.Stat(filepath.Join(fpath, name))
+		}, func(name string) (*GitIgnore, error) {
+			return CompileIgnoreFile(filepath.Join(fpath, name))
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// CompileRepositoryTiersFS is like CompileRepositoryTiers, but walks the tree
+// rooted at `root` in `fsys`. The parents of `root` are not looked at, so the
+// tiers which require git only apply below a directory of `fsys` containing a
+// ".git" entry.
+func CompileRepositoryTiersFS(fsys fs.FS, root string, tiers ...IgnoreTier) (*Repository, error) {
+	r := newRepository(root, tiers)
+
+	err := fs.WalkDir(fsys, root, func(fpath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if d.Name() == gitDir && fpath != root {
+			return fs.SkipDir
+		}
+		dir := fsRel(root, fpath)
+		if dir != "" && r.MatchesPathIsDir(dir, true) {
+			return fs.SkipDir
+		}
+		return r.discover(dir, func(name string) (fs.FileInfo, error) {
+			return fs.Stat(fsys, path.Join(fpath, name))
+		}, func(name string) (*GitIgnore, error) {
+			return CompileIgnoreFS(fsys, path.Join(fpath, name))
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// discover records the ".git" entry and compiles the ignore files of every
+// tier in the slash separated directory `dir`, before any of its entries are
+// visited, so that the ignored subdirectories are never walked, as git does.
+// The entries of the directory are looked up with `stat`, and the ignore files
+// are compiled with `compile`.
+func (r *Repository) discover(dir string, stat func(name string) (fs.FileInfo, error),
+	compile func(name string) (*GitIgnore, error)) error {
+	if _, err := stat(gitDir); err == nil {
+		r.addGitRoot(dir)
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	for _, t := range r.tiers {
+		info, err := stat(t.Name)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			continue
+		}
+		gi, err := compile(t.Name)
+		if err != nil {
+			return err
+		}
+		r.add(t, dir, gi)
+	}
+	return nil
+}
+
+// SetIgnoreCase makes every ignore file of the Repository match regardless of
+// case, like WithIgnoreCase. The ignore files of the directories are then also
+// found regardless of case. It must not be called while the Repository is
+// matching paths from other goroutines.
+func (r *Repository) SetIgnoreCase(ignoreCase bool) {
+	r.ignoreCase = ignoreCase
+	for _, t := range r.tiers {
+		for _, gi := range t.ignores {
+			gi.setIgnoreCase(ignoreCase)
+		}
+	}
+	for _, gi := range r.excludes {
+		gi.setIgnoreCase(ignoreCase)
+	}
+}
+
+// toSlashDir converts a relative directory into the form used as a key in
+// `repositoryTier.ignores`.
+func toSlashDir(dir string) string {
+	dir = filepath.ToSlash(dir)
+	if dir == "." {
+		return ""
+	}
+	return dir
+}
+
+////////////////////////////////////////////////////////////
+
+// MatchesPath returns true if the given Repository would ignore the path `f`.
+// A trailing slash marks `f` as a directory.
+func (r *Repository) MatchesPath(f string) bool {
+	matchesPath, _ := r.MatchesPathHow(f)
+	return matchesPath
+}
+
+// MatchesPathHow returns true, `pattern` if the given Repository would ignore
+// the path `f`. The path is either relative to the root of the work tree, or
+// an absolute path inside of it.
+func (r *Repository) MatchesPathHow(f string) (bool, *IgnorePattern) {
+	f, isDir := splitDirSuffix(f)
+	return r.MatchesPathIsDirHow(f, isDir)
+}
+
+// MatchesPathIsDir returns true if the given Repository would ignore the path
+// `f`, which is a directory if `isDir` is set.
+func (r *Repository) MatchesPathIsDir(f string, isDir bool) bool {
+	matchesPath, _ := r.MatchesPathIsDirHow(f, isDir)
+	return matchesPath
+}
+
+// MatchesPathIsDirHow returns true, `pattern` if the given Repository would
+// ignore the path `f`, which is a directory if `isDir` is set.
+func (r *Repository) MatchesPathIsDirHow(f string, isDir bool) (bool, *IgnorePattern) {
+	return r.Match(f, isDir).how()
+}
+
+// Match returns whether the Repository ignores the path `f`, which is a
+// directory if `isDir` is set, re-includes it, or does not mention it, along
+// with the pattern which decided. The path is either relative to the root of
+// the work tree, or an absolute path inside of it. The paths outside of the
+// work tree are never matched.
+func (r *Repository) Match(f string, isDir bool) MatchResult {
+	rel, ok := r.relPath(f)
+	if !ok {
+		return MatchResult{Status: NoMatch}
+	}
+	return matchWithParents(r, rel, isDir)
+}
+
+// relPath cleans the path `f` like cleanPath, and first makes it relative to
+// the root of the work tree if it is absolute. It reports false if `f` is not
+// inside of the work tree.
+func (r *Repository) relPath(f string) (string, bool) {
+	if filepath.IsAbs(f) {
+		rel, err := filepath.Rel(r.root, f)
+		if err != nil {
+			return cleanPath(f), false
+		}
+		f = rel
+	}
+	f = cleanPath(f)
+	if f == ".." || strings.HasPrefix(f, "../") {
+		return f, false
+	}
+	return f, true
+}
+
+// lastMatch consults the tiers in order, and returns the pattern mentioning
+// `f` from the first one which has one, followed by the excludes.
+func (r *Repository) lastMatch(f string, isDir bool) *IgnorePattern {
+	for _, t := range r.tiers {
+		if ip := r.tierMatch(t, f, isDir); ip != nil {
+			return ip
+		}
+	}
+	for _, gi := range r.excludes {
+		if ip := gi.lastMatch(f, isDir); ip != nil {
+			return ip
+		}
+	}
+	return nil
+}
+
+// tierMatch consults the ignore files of the tier `t` from the deepest
+// directory containing `f` up to the root, and returns the last pattern
+// mentioning `f` in the first file which has one.
+func (r *Repository) tierMatch(t *repositoryTier, f string, isDir bool) *IgnorePattern {
+	top := -1
+	if t.RequireGit {
+		var ok bool
+		if top, ok = r.gitTop(f); !ok {
+			return nil
+		}
+	}
+	for i := len(f) - 1; i >= top; i-- {
+		if i >= 0 && f[i] != '/' {
+			continue
+		}
+		dir, rel := "", f
+		if i >= 0 {
+			dir, rel = f[:i], f[i+1:]
+		}
+		gi, ok := t.ignores[r.dirKey(dir)]
+		if !ok {
+			continue
+		}
+		if ip := gi.lastMatch(rel, isDir); ip != nil {
+			return ip
+		}
+	}
+	return nil
+}
+
+// gitTop returns the length of the deepest directory containing the path `f`
+// which contains a ".git" entry, or -1 for the root, so that the tiers which
+// require git are only consulted in the directories at least as deep. It
+// reports false if `f` is not inside of a git repository.
+func (r *Repository) gitTop(f string) (int, bool) {
+	for i := len(f) - 1; i >= 0; i-- {
+		if f[i] == '/' && r.gitRoots[r.dirKey(f[:i])] {
+			return i, true
+		}
+	}
+	return -1, r.gitRoots[""] || r.inGit
+}
+
+////////////////////////////////////////////////////////////
@@ -0,0 +1,269 @@
+// Implement tests for the `Repository` type
+package ignore
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+////////////////////////////////////////////////////////////
+
+// Helper function to create a work tree in a temporary directory. The keys of
+// `files` are slash separated paths relative to the root of the tree, and the
+// values are the contents of the files.
+func writeTestTree(t *testing.T, files map[string]string) string {
+	root, err := ioutil.TempDir("", "go-gitignore")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		fpath := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(fpath), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(fpath, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+////////////////////////////////////////////////////////////
+
+func TestCompileRepository_InvalidRoot(t *testing.T) {
+	object, err := CompileRepository("./test_fixtures/invalid.dir")
+	assert.Nil(t, object, "object should be nil")
+	assert.NotNil(t, err, "err should be unknown file / dir")
+}
+
+// Validate that patterns are anchored to the directory of their ignore file
+func TestCompileRepository_NestedAnchoring(t *testing.T) {
+	root := writeTestTree(t, map[string]string{
+		".gitignore":              "*.log\n",
+		"services/api/.gitignore": "build/\n/local.txt\n",
+		"services/api/main.go":    "",
+	})
+	defer os.RemoveAll(root)
+
+	object, err := CompileRepository(root)
+	assert.Nil(t, err, "err should be nil")
+	assert.NotNil(t, object, "object should not be nil")
+
+	assert.Equal(t, true, object.MatchesPath("debug.log"), "debug.log should match")
+	assert.Equal(t, true, object.MatchesPath("services/api/debug.log"), "services/api/debug.log should match")
+	assert.Equal(t, true, object.MatchesPath("services/api/build/out.bin"), "services/api/build/out.bin should match")
+	assert.Equal(t, true, object.MatchesPath("services/api/pkg/build/out.bin"), "services/api/pkg/build/out.bin should match")
+	assert.Equal(t, true, object.MatchesPath("services/api/local.txt"), "services/api/local.txt should match")
+
+	assert.Equal(t, false, object.MatchesPath("build/out.bin"), "build/out.bin should not match")
+	assert.Equal(t, false, object.MatchesPath("services/build/out.bin"), "services/build/out.bin should not match")
+	assert.Equal(t, false, object.MatchesPath("local.txt"), "local.txt should not match")
+	assert.Equal(t, false, object.MatchesPath("services/api/pkg/local.txt"), "services/api/pkg/local.txt should not match")
+	assert.Equal(t, false, object.MatchesPath("services/api/main.go"), "services/api/main.go should not match")
+}
+
+// Validate that deeper ignore files override the ones above them
+func TestCompileRepository_Precedence(t *testing.T) {
+	root := writeTestTree(t, map[string]string{
+		".gitignore":        "*.txt\n!keep.md\n",
+		"docs/.gitignore":   "!*.txt\n*.md\n",
+		"docs/a/.gitignore": "# only comments\n",
+	})
+	defer os.RemoveAll(root)
+
+	object, err := CompileRepository(root)
+	assert.Nil(t, err, "err should be nil")
+
+	assert.Equal(t, true, object.MatchesPath("notes.txt"), "notes.txt should match")
+	assert.Equal(t, false, object.MatchesPath("docs/notes.txt"), "docs/notes.txt should not match")
+	assert.Equal(t, false, object.MatchesPath("docs/a/notes.txt"), "docs/a/notes.txt should not match")
+	assert.Equal(t, true, object.MatchesPath("docs/keep.md"), "docs/keep.md should match")
+	assert.Equal(t, false, object.MatchesPath("keep.md"), "keep.md should not match")
+}
+
+// Validate that MatchesPathHow reports the deciding pattern
+func TestCompileRepository_MatchesPathHow(t *testing.T) {
+	root := writeTestTree(t, map[string]string{
+		".gitignore":     "# comment\n*.o\n",
+		"lib/.gitignore": "\n\n/gen\n",
+	})
+	defer os.RemoveAll(root)
+
+	object, err := CompileRepository(root)
+	assert.Nil(t, err, "err should be nil")
+
+	matchesPath, reason := object.MatchesPathHow("lib/gen/x.c")
+	assert.Equal(t, true, matchesPath, "lib/gen/x.c should match")
+	assert.NotNil(t, reason, "reason should not be nil")
+	assert.Equal(t, 3, reason.LineNo, "should match with line 3")
+	assert.Equal(t, "/gen", reason.Line, "should match with line /gen")
+
+	matchesPath, reason = object.MatchesPathHow(filepath.Join(root, "lib", "x.o"))
+	assert.Equal(t, true, matchesPath, "absolute lib/x.o should match")
+	assert.NotNil(t, reason, "reason should not be nil")
+	assert.Equal(t, 2, reason.LineNo, "should match with line 2")
+
+	matchesPath, reason = object.MatchesPathHow("gen/x.c")
+	assert.Equal(t, false, matchesPath, "gen/x.c should not match")
+	assert.Nil(t, reason, "reason should be nil as no match should happen")
+}
+
+// Validate that the paths outside of the work tree are never matched
+func TestCompileRepository_OutsidePath(t *testing.T) {
+	root := writeTestTree(t, map[string]string{
+		".gitignore": "*.o\n",
+	})
+	defer os.RemoveAll(root)
+
+	object, err := CompileRepository(root)
+	assert.Nil(t, err, "err should be nil")
+
+	outside := filepath.Join(filepath.Dir(root), "other", "x.o")
+	assert.Equal(t, NoMatch, object.Match(outside, false).Status, "a path outside of the root should not match")
+	assert.Equal(t, NoMatch, object.Match("../x.o", false).Status, "../x.o should not match")
+	assert.Equal(t, NoMatch, object.Explain(outside, false).Result.Status, "a path outside of the root should not be explained")
+	assert.Equal(t, Ignored, object.Match(filepath.Join(root, "x.o"), false).Status, "x.o should match")
+
+	object, err = CompileRepository(".")
+	assert.Nil(t, err, "err should be nil")
+	assert.Equal(t, NoMatch, object.Match(outside, false).Status, "a path which is not relative to the root should not match")
+}
+
+// Validate that ignore files inside of ".git" are not consulted
+func TestCompileRepository_SkipsGitDir(t *testing.T) {
+	root := writeTestTree(t, map[string]string{
+		".git/.gitignore": "*\n",
+		"a.txt":           "",
+	})
+	defer os.RemoveAll(root)
+
+	object, err := CompileRepository(root)
+	assert.Nil(t, err, "err should be nil")
+	assert.Equal(t, false, object.MatchesPath("a.txt"), "a.txt should not match")
+}
+
+// Validate that the ignored directories are not walked, so that their ignore
+// files are not read and unreadable contents are not an error
+func TestCompileRepository_SkipsIgnoredDirs(t *testing.T) {
+	root := writeTestTree(t, map[string]string{
+		".gitignore":                  ".cache/\nnode_modules/\n",
+		".cache/.gitignore":           "*.go\n",
+		"node_modules/.gitignore":     "!*.js\n",
+		"node_modules/dep/.gitignore": "[invalid\n",
+		"src/.gitignore":              "*.tmp\n",
+	})
+	defer os.RemoveAll(root)
+	locked := filepath.Join(root, "node_modules", "locked")
+	assert.Nil(t, os.Mkdir(locked, 0), "err should be nil")
+	defer os.Chmod(locked, 0755)
+
+	object, err := CompileRepository(root)
+	assert.Nil(t, err, "err should be nil")
+	assert.Equal(t, 2, len(object.tiers[0].ignores), "only .gitignore and src/.gitignore should be read")
+	assert.Equal(t, true, object.MatchesPath("src/a.tmp"), "src/a.tmp should match")
+	assert.Equal(t, true, object.MatchesPath("node_modules/a.js"), "node_modules/a.js should match")
+	assert.Equal(t, []string{"node_modules/ decided"}, explainSteps(object.Explain("node_modules/a.js", false)))
+
+	object, err = CompileRepositoryFS(os.DirFS(root), ".")
+	assert.Nil(t, err, "err should be nil")
+	assert.Equal(t, 2, len(object.tiers[0].ignores), "only .gitignore and src/.gitignore should be read")
+}
+
+// Validate the directory flag for directory only patterns
+func TestCompileRepository_MatchesPathIsDir(t *testing.T) {
+	root := writeTestTree(t, map[string]string{
+		".gitignore":     "out/\n",
+		"pkg/.gitignore": "!out/\n",
+	})
+	defer os.RemoveAll(root)
+
+	object, err := CompileRepository(root)
+	assert.Nil(t, err, "err should be nil")
+
+	var _ DirIgnoreParser = object
+
+	assert.Equal(t, true, object.MatchesPathIsDir("out", true), "out should match as a directory")
+	assert.Equal(t, false, object.MatchesPathIsDir("out", false), "out should not match as a file")
+	assert.Equal(t, true, object.MatchesPathIsDir("out/a.txt", false), "out/a.txt should match")
+	assert.Equal(t, false, object.MatchesPathIsDir("pkg/out", true), "pkg/out should not match as a directory")
+	assert.Equal(t, false, object.MatchesPathIsDir("pkg/out/a.txt", false), "pkg/out/a.txt should not match")
+}
+
+func TestCompileRepositoryFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"repo/.gitignore":           {Data: []byte("*.log\n")},
+		"repo/.git/info/.gitignore": {Data: []byte("*\n")},
+		"repo/api/.gitignore":       {Data: []byte("/local.txt\n!keep.log\n")},
+		"repo/api/main.go":          {},
+	}
+
+	object, err := CompileRepositoryFS(fsys, "repo")
+	assert.Nil(t, err, "err should be nil")
+	assert.NotNil(t, object, "object should not be nil")
+
+	assert.Equal(t, true, object.MatchesPath("debug.log"), "debug.log should match")
+	assert.Equal(t, true, object.MatchesPath("api/local.txt"), "api/local.txt should match")
+	assert.Equal(t, false, object.MatchesPath("api/keep.log"), "api/keep.log should not match")
+	assert.Equal(t, false, object.MatchesPath("api/main.go"), "api/main.go should not match")
+	assert.Equal(t, false, object.MatchesPath("local.txt"), "local.txt should not match")
+
+	object, err = CompileRepositoryFS(fsys, "missing")
+	assert.Nil(t, object, "object should be nil")
+	assert.NotNil(t, err, "err should be unknown file / dir")
+}
+
+// Validate that the ignore files of the directories are found regardless of
+// case once the Repository ignores case
+func TestRepository_IgnoreCase(t *testing.T) {
+	fsys := fstest.MapFS{
+		"sub/.gitignore":     {Data: []byte("*.tmp\n")},
+		"Lib/.git":           {Data: []byte("gitdir: ../.git/modules/lib\n")},
+		"Lib/pkg/.gitignore": {Data: []byte("*.o\n")},
+	}
+
+	object, err := CompileRepositoryTiersFS(fsys, ".", IgnoreTier{Name: GitIgnoreFile, RequireGit: true})
+	assert.Nil(t, err, "err should be nil")
+	assert.Equal(t, false, object.MatchesPath("SUB/a.tmp"), "SUB/a.tmp should not match")
+	assert.Equal(t, true, object.MatchesPath("Lib/pkg/a.o"), "Lib/pkg/a.o should match")
+	assert.Equal(t, false, object.MatchesPath("lib/PKG/a.o"), "lib/PKG/a.o should not match")
+
+	object.SetIgnoreCase(true)
+	assert.Equal(t, false, object.MatchesPath("SUB/a.tmp"), "sub/.gitignore should not apply outside of a repository")
+	assert.Equal(t, true, object.MatchesPath("lib/PKG/a.O"), "lib/PKG/a.O should match")
+	assert.Equal(t, 1, len(object.Explain("LIB/Pkg/a.o", false).Steps), "the pattern should be explained")
+
+	object, err = CompileRepositoryFS(fsys, ".")
+	assert.Nil(t, err, "err should be nil")
+	object.SetIgnoreCase(true)
+	assert.Equal(t, true, object.MatchesPath("SUB/a.tmp"), "SUB/a.tmp should match")
+	assert.Equal(t, true, object.MatchesPath("sub/A.TMP"), "sub/A.TMP should match")
+
+	object.SetIgnoreCase(false)
+	assert.Equal(t, false, object.MatchesPath("SUB/a.tmp"), "SUB/a.tmp should not match")
+}
+
+// Validate that patterns remember the file and directory they came from
+func TestRepository_Source(t *testing.T) {
+	root := writeTestTree(t, map[string]string{
+		".gitignore":          "*.log\n",
+		"services/.gitignore": "# comment\n/local.txt\n",
+	})
+	defer os.RemoveAll(root)
+
+	object, err := CompileRepository(root)
+	assert.Nil(t, err, "err should be nil")
+
+	_, how := object.MatchesPathHow("services/local.txt")
+	assert.Equal(t, filepath.Join(root, "services", ".gitignore"), how.Source, "should match in services/.gitignore")
+	assert.Equal(t, "services", how.Base, "should be anchored to services")
+	assert.Equal(t, 2, how.LineNo, "should match with line 2")
+
+	_, how = object.MatchesPathHow("services/debug.log")
+	assert.Equal(t, filepath.Join(root, ".gitignore"), how.Source, "should match in .gitignore")
+	assert.Equal(t, "", how.Base, "should be anchored to the root")
+}
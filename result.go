@@ -0,0 +1,79 @@
+package ignore
+
+////////////////////////////////////////////////////////////
+
+// MatchStatus tells how a path was decided by a matcher.
+type MatchStatus int
+
+const (
+	// NoMatch is the status of the paths which no pattern mentions.
+	NoMatch MatchStatus = iota
+
+	// Ignored is the status of the paths which are excluded by a pattern,
+	// or which are inside of an excluded directory [Rule 4].
+	Ignored
+
+	// Whitelisted is the status of the paths which are re-included by a
+	// negated pattern [Rule 4].
+	Whitelisted
+)
+
+// String implements fmt.Stringer.
+func (s MatchStatus) String() string {
+	switch s {
+	case NoMatch:
+		return "no match"
+	case Ignored:
+		return "ignored"
+	case Whitelisted:
+		return "whitelisted"
+	}
+	return "unknown"
+}
+
+// MatchResult is the verdict of a matcher for a path.
+type MatchResult struct {
+	Status MatchStatus
+
+	// Pattern is the pattern which decided the Status. It is the pattern
+	// excluding a parent directory if one is excluded, and nil for NoMatch.
+	Pattern *IgnorePattern
+}
+
+// Ignored reports whether the path is ignored.
+func (r MatchResult) Ignored() bool {
+	return r.Status == Ignored
+}
+
+// how returns the result in the form of the MatchesPathHow methods, which
+// only report the excluding patterns.
+func (r MatchResult) how() (bool, *IgnorePattern) {
+	if r.Status != Ignored {
+		return false, nil
+	}
+	return true, r.Pattern
+}
+
+// resultOf returns the result decided by the pattern `ip`, which may be nil.
+func resultOf(ip *IgnorePattern) MatchResult {
+	switch {
+	case ip == nil:
+		return MatchResult{Status: NoMatch}
+	case ip.Negate:
+		return MatchResult{Status: Whitelisted, Pattern: ip}
+	}
+	return MatchResult{Status: Ignored, Pattern: ip}
+}
+
+////////////////////////////////////////////////////////////
+
+// ResultIgnoreParser is implemented by the matchers which can tell the paths
+// they re-include apart from the ones they do not mention, such as GitIgnore
+// and Repository. This lets matchers be layered: a path is decided by the
+// first layer whose Status is not NoMatch.
+type ResultIgnoreParser interface {
+	DirIgnoreParser
+	Match(f string, isDir bool) MatchResult
+}
+
+////////////////////////////////////////////////////////////
@@ -0,0 +1,67 @@
+// Implement tests for `MatchResult`
+package ignore
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+////////////////////////////////////////////////////////////
+
+func TestGitIgnore_Match(t *testing.T) {
+	object := CompileIgnoreLines("*.log", "!keep.log", "build/", "!build/keep.txt")
+	var _ ResultIgnoreParser = object
+
+	result := object.Match("debug.log", false)
+	assert.Equal(t, Ignored, result.Status, "debug.log should be ignored")
+	assert.Equal(t, 1, result.Pattern.LineNo, "debug.log should be ignored by line 1")
+
+	result = object.Match("a/keep.log", false)
+	assert.Equal(t, Whitelisted, result.Status, "a/keep.log should be whitelisted")
+	assert.Equal(t, 2, result.Pattern.LineNo, "a/keep.log should be whitelisted by line 2")
+
+	result = object.Match("main.go", false)
+	assert.Equal(t, NoMatch, result.Status, "main.go should not be matched")
+	assert.Nil(t, result.Pattern, "main.go should not have a pattern")
+
+	// The negation can not re-include a file in an excluded directory
+	result = object.Match("build/keep.txt", false)
+	assert.Equal(t, Ignored, result.Status, "build/keep.txt should be ignored")
+	assert.Equal(t, 3, result.Pattern.LineNo, "build/keep.txt should be ignored by its parent")
+
+	// A whitelisted directory does not decide the files inside of it
+	object = CompileIgnoreLines("/*", "!/src/")
+	assert.Equal(t, Whitelisted, object.Match("src", true).Status, "src/ should be whitelisted")
+	assert.Equal(t, NoMatch, object.Match("src/main.go", false).Status, "src/main.go should not be matched")
+	assert.Equal(t, Ignored, object.Match("README", false).Status, "README should be ignored")
+}
+
+func TestRepository_Match(t *testing.T) {
+	root := writeTestTree(t, map[string]string{
+		".gitignore":      "*.txt\n",
+		"docs/.gitignore": "!*.txt\n",
+	})
+	defer os.RemoveAll(root)
+
+	object, err := CompileRepository(root)
+	assert.Nil(t, err, "err should be nil")
+	var _ ResultIgnoreParser = object
+
+	result := object.Match("a.txt", false)
+	assert.Equal(t, Ignored, result.Status, "a.txt should be ignored")
+
+	result = object.Match("docs/a.txt", false)
+	assert.Equal(t, Whitelisted, result.Status, "docs/a.txt should be whitelisted")
+	assert.Equal(t, "docs", result.Pattern.Base, "docs/a.txt should be whitelisted by docs/.gitignore")
+
+	assert.Equal(t, NoMatch, object.Match("docs/a.md", false).Status, "docs/a.md should not be matched")
+}
+
+func TestMatchStatus_String(t *testing.T) {
+	assert.Equal(t, "no match", NoMatch.String())
+	assert.Equal(t, "ignored", Ignored.String())
+	assert.Equal(t, "whitelisted", Whitelisted.String())
+	assert.Equal(t, "unknown", MatchStatus(42).String())
+}
@@ -0,0 +1,79 @@
+package ignore
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+////////////////////////////////////////////////////////////
+
+// IgnoreTier is a kind of ignore file looked for in every directory of a
+// Repository, such as ".gitignore" or ".ignore". When a Repository has several
+// tiers, the path is first looked up in the ignore files of the tier with the
+// highest precedence, from the deepest directory up to the root as usual, and
+// the tiers with a lower precedence are only consulted if none of them
+// mentions it. For example, a "!" pattern of a ".ignore" file re-includes a
+// path excluded by any ".gitignore" file.
+type IgnoreTier struct {
+	// Name is the name of the ignore files of the tier.
+	Name string
+
+	// RequireGit makes the ignore files of the tier only apply inside of a
+	// git repository, that is below a directory containing a ".git" entry,
+	// and not above the root of the innermost repository. This is how
+	// ripgrep and fd treat ".gitignore" files.
+	RequireGit bool
+}
+
+var (
+	// GitTiers are the tiers of git, used by CompileRepository.
+	GitTiers = []IgnoreTier{
+		{Name: GitIgnoreFile},
+	}
+
+	// RipgrepTiers are the tiers of ripgrep.
+	RipgrepTiers = []IgnoreTier{
+		{Name: ".rgignore"},
+		{Name: ".ignore"},
+		{Name: GitIgnoreFile, RequireGit: true},
+	}
+
+	// FdTiers are the tiers of fd.
+	FdTiers = []IgnoreTier{
+		{Name: ".fdignore"},
+		{Name: ".ignore"},
+		{Name: GitIgnoreFile, RequireGit: true},
+	}
+)
+
+// requireGit reports whether any of the `tiers` requires git.
+func requireGit(tiers []IgnoreTier) bool {
+	for _, t := range tiers {
+		if t.RequireGit {
+			return true
+		}
+	}
+	return false
+}
+
+// parentInGit reports whether a parent directory of `root` contains a ".git"
+// entry, so that `root` is inside of a git repository.
+func parentInGit(root string) (bool, error) {
+	dir, err := filepath.Abs(root)
+	if err != nil {
+		return false, err
+	}
+	for parent := filepath.Dir(dir); parent != dir; dir, parent = parent, filepath.Dir(parent) {
+		_, err := os.Stat(filepath.Join(parent, gitDir))
+		if err == nil {
+			return true, nil
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return false, err
+		}
+	}
+	return false, nil
+}
+
+////////////////////////////////////////////////////////////
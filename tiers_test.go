@@ -0,0 +1,119 @@
+// Implement tests for the tiers of ignore files of `CompileRepositoryTiers`
+package ignore
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+////////////////////////////////////////////////////////////
+
+// Validate that a tier with a higher precedence decides before the others,
+// even from a shallower directory
+func TestCompileRepositoryTiers_Precedence(t *testing.T) {
+	root := writeTestTree(t, map[string]string{
+		".git/HEAD":      "",
+		".gitignore":     "*.log\n*.tmp\n",
+		".ignore":        "!keep.log\n*.txt\n",
+		"sub/.rgignore":  "!notes.txt\n",
+		"sub/.gitignore": "!*.tmp\n",
+	})
+	defer os.RemoveAll(root)
+
+	object, err := CompileRepositoryTiers(root, RipgrepTiers...)
+	assert.Nil(t, err, "err should be nil")
+
+	assert.Equal(t, true, object.MatchesPath("x.log"), "x.log should be ignored by .gitignore")
+	assert.Equal(t, false, object.MatchesPath("sub/keep.log"), "sub/keep.log should be re-included by .ignore")
+	assert.Equal(t, false, object.MatchesPath("sub/x.tmp"), "sub/x.tmp should be re-included by sub/.gitignore")
+	assert.Equal(t, true, object.MatchesPath("a.txt"), "a.txt should be ignored by .ignore")
+	assert.Equal(t, false, object.MatchesPath("sub/notes.txt"), "sub/notes.txt should be re-included by sub/.rgignore")
+	assert.Equal(t, true, object.MatchesPath("sub/other.txt"), "sub/other.txt should be ignored by .ignore")
+
+	e := object.Explain("sub/keep.log", false)
+	assert.Equal(t, 2, len(e.Steps), "both tiers should be explained")
+	assert.Equal(t, "*.log", e.Steps[0].Pattern.Line)
+	assert.Equal(t, Overridden, e.Steps[0].Outcome)
+	assert.Equal(t, "!keep.log", e.Steps[1].Pattern.Line)
+	assert.Equal(t, Decided, e.Steps[1].Outcome)
+
+	// With the tiers of git, the other ignore files are not read
+	object, err = CompileRepository(root)
+	assert.Nil(t, err, "err should be nil")
+	assert.Equal(t, false, object.MatchesPath("a.txt"), "a.txt should not be ignored")
+	assert.Equal(t, true, object.MatchesPath("sub/keep.log"), "sub/keep.log should be ignored")
+}
+
+// Validate that the tiers requiring git only apply inside of a repository, up
+// to the root of the innermost one
+func TestCompileRepositoryTiers_RequireGit(t *testing.T) {
+	fsys := mapFS(map[string]string{
+		".gitignore":                 "*.log\n",
+		".ignore":                    "*.tmp\n",
+		"repo/.git/HEAD":             "",
+		"repo/.gitignore":            "*.bak\n",
+		"repo/vendor/lib/.git":       "gitdir: ../../.git/modules/lib\n",
+		"repo/vendor/lib/.gitignore": "*.o\n",
+	})
+
+	object, err := CompileRepositoryTiersFS(fsys, ".", RipgrepTiers...)
+	assert.Nil(t, err, "err should be nil")
+
+	assert.Equal(t, false, object.MatchesPath("a.log"), ".gitignore should not apply outside of a repository")
+	assert.Equal(t, true, object.MatchesPath("a.tmp"), ".ignore should apply outside of a repository")
+	assert.Equal(t, false, object.MatchesPath("repo/a.log"), ".gitignore should not apply above the repository")
+	assert.Equal(t, true, object.MatchesPath("repo/a.bak"), "repo/.gitignore should apply")
+	assert.Equal(t, true, object.MatchesPath("repo/a.tmp"), ".ignore should apply in the repository")
+	assert.Equal(t, true, object.MatchesPath("repo/vendor/lib/a.o"), "repo/vendor/lib/.gitignore should apply")
+	assert.Equal(t, false, object.MatchesPath("repo/vendor/lib/a.bak"), "repo/.gitignore should not apply in a nested repository")
+	assert.Equal(t, true, object.MatchesPath("repo/vendor/a.bak"), "repo/.gitignore should apply above the nested repository")
+
+	// Without requiring git, every .gitignore file applies
+	object, err = CompileRepositoryTiersFS(fsys, ".", IgnoreTier{Name: ".ignore"}, IgnoreTier{Name: GitIgnoreFile})
+	assert.Nil(t, err, "err should be nil")
+	assert.Equal(t, true, object.MatchesPath("a.log"), "a.log should be ignored")
+	assert.Equal(t, true, object.MatchesPath("repo/vendor/lib/a.bak"), "repo/vendor/lib/a.bak should be ignored")
+}
+
+// Validate that a work tree inside of a repository is detected from its parents
+func TestCompileRepositoryTiers_ParentInGit(t *testing.T) {
+	root := writeTestTree(t, map[string]string{
+		".git/HEAD":      "",
+		"sub/.gitignore": "*.log\n",
+	})
+	defer os.RemoveAll(root)
+
+	object, err := CompileRepositoryTiers(root+"/sub", RipgrepTiers...)
+	assert.Nil(t, err, "err should be nil")
+	assert.Equal(t, true, object.MatchesPath("a.log"), "a.log should be ignored")
+}
+
+// Validate that the exclude files are not read outside of a repository when a
+// tier requires git
+func TestLoadRepositoryTiers_RequireGit(t *testing.T) {
+	home := writeTestTree(t, map[string]string{
+		".config/git/ignore": "*.log\n",
+	})
+	defer os.RemoveAll(home)
+	defer setTestHome(home, "")()
+
+	root := writeTestTree(t, map[string]string{
+		".ignore": "*.tmp\n",
+	})
+	defer os.RemoveAll(root)
+
+	object, err := LoadRepositoryTiers(root, FdTiers...)
+	assert.Nil(t, err, "err should be nil")
+	assert.Equal(t, true, object.MatchesPath("a.tmp"), "a.tmp should be ignored")
+	if inGit, _ := parentInGit(root); !inGit {
+		assert.Equal(t, false, object.MatchesPath("a.log"), "a.log should not be ignored outside of a repository")
+	}
+
+	object, err = LoadRepositoryTiers(root, IgnoreTier{Name: ".ignore"})
+	assert.Nil(t, err, "err should be nil")
+	assert.Equal(t, true, object.MatchesPath("a.log"), "a.log should be ignored by the global excludes file")
+}
+
+////////////////////////////////////////////////////////////
@@ -0,0 +1,175 @@
+package ignore
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+////////////////////////////////////////////////////////////
+
+// WalkDir walks the file tree rooted at `root` like filepath.WalkDir, but only
+// calls `fn` for the entries which are not ignored. Ignored directories are
+// not descended into [Rule 4], and so their contents are never read.
+//
+// Paths are matched against `m` relative to `root`, with the directory flag
+// taken from the entry. If `m` is nil, the ".gitignore" files in the tree are
+// discovered while walking it, as with CompileRepository, and the ".git"
+// directory is skipped.
+func WalkDir(root string, m DirIgnoreParser, fn fs.WalkDirFunc) error {
+	return filepath.WalkDir(root, newWalker(root, m).walkDirFunc(fn))
+}
+
+// Walk is like WalkDir, but calls `fn` with the os.FileInfo of each entry, as
+// filepath.Walk does.
+func Walk(root string, m DirIgnoreParser, fn filepath.WalkFunc) error {
+	w := newWalker(root, m)
+	return filepath.Walk(root, func(fpath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return fn(fpath, info, err)
+		}
+		ignored, err := w.ignored(fpath, info.Name(), info.IsDir())
+		if err != nil {
+			return err
+		}
+		if ignored {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		return fn(fpath, info, nil)
+	})
+}
+
+// WalkDirFS is like WalkDir, but walks the tree at `root` in `fsys`, as
+// fs.WalkDir does. Ignore files are discovered in `fsys` if `m` is nil.
+func WalkDirFS(fsys fs.FS, root string, m DirIgnoreParser, fn fs.WalkDirFunc) error {
+	return fs.WalkDir(fsys, root, newFSWalker(fsys, root, m).walkDirFunc(fn))
+}
+
+////////////////////////////////////////////////////////////
+
+// walker holds the state shared by the callbacks of Walk, WalkDir and
+// WalkDirFS.
+type walker struct {
+	m DirIgnoreParser
+
+	// repo is set when the ignore files are discovered during the walk. It
+	// is then also used as `m`.
+	repo *Repository
+
+	// rel returns the slash separated path of `fpath` relative to the root
+	// of the walk.
+	rel func(fpath string) (string, error)
+
+	// compile compiles the ignore file in the directory `dir`.
+	compile func(dir string) (*GitIgnore, error)
+}
+
+// newWalker returns a walker for the tree at `root` on the OS file system.
+func newWalker(root string, m DirIgnoreParser) *walker {
+	w := &walker{
+		m: m,
+		rel: func(fpath string) (string, error) {
+			rel, err := filepath.Rel(root, fpath)
+			return toSlashDir(rel), err
+		},
+		compile: func(dir string) (*GitIgnore, error) {
+			return CompileIgnoreFile(filepath.Join(dir, GitIgnoreFile))
+		},
+	}
+	w.discover(root)
+	return w
+}
+
+// newFSWalker returns a walker for the tree at `root` in `fsys`.
+func newFSWalker(fsys fs.FS, root string, m DirIgnoreParser) *walker {
+	w := &walker{
+		m: m,
+		rel: func(fpath string) (string, error) {
+			return fsRel(root, fpath), nil
+		},
+		compile: func(dir string) (*GitIgnore, error) {
+			return CompileIgnoreFS(fsys, path.Join(dir, GitIgnoreFile))
+		},
+	}
+	w.discover(root)
+	return w
+}
+
+// discover makes the walker collect the ignore files in the tree at `root` if
+// no matcher was given.
+func (w *walker) discover(root string) {
+	if w.m == nil {
+		w.repo = newRepository(root, GitTiers)
+		w.m = w.repo
+	}
+}
+
+// ignored reports whether the entry `name` at `fpath` is to be skipped. When
+// discovering ignore files, the one in each directory which is not skipped is
+// compiled before any of its entries are visited.
+func (w *walker) ignored(fpath, name string, isDir bool) (bool, error) {
+	rel, err := w.rel(fpath)
+	if err != nil {
+		return false, err
+	}
+
+	if rel != "" {
+		if w.repo != nil && isDir && name == gitDir {
+			return true, nil
+		}
+		if w.m.MatchesPathIsDir(rel, isDir) {
+			return true, nil
+		}
+	}
+
+	if w.repo != nil && isDir {
+		gi, err := w.compile(fpath)
+		if err == nil {
+			w.repo.add(w.repo.tiers[0], rel, gi)
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return false, err
+		}
+	}
+	return false, nil
+}
+
+// walkDirFunc wraps `fn` so that it is only called for the entries which are
+// not ignored.
+func (w *walker) walkDirFunc(fn fs.WalkDirFunc) fs.WalkDirFunc {
+	return func(fpath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return fn(fpath, d, err)
+		}
+		ignored, err := w.ignored(fpath, d.Name(), d.IsDir())
+		if err != nil {
+			return err
+		}
+		if ignored {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		return fn(fpath, d, nil)
+	}
+}
+
+// fsRel returns the path `fpath` from an fs.FS relative to `root`, in the form
+// used as a key in `repositoryTier.ignores`.
+func fsRel(root, fpath string) string {
+	if root == "." {
+		return toSlashDir(fpath)
+	}
+	if fpath == root {
+		return ""
+	}
+	return strings.TrimPrefix(fpath, root+"/")
+}
+
+////////////////////////////////////////////////////////////
@@ -0,0 +1,156 @@
+// Implement tests for `Walk` and `WalkDir`
+package ignore
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+////////////////////////////////////////////////////////////
+
+// Helper function which returns the slash separated paths visited by WalkDir,
+// relative to `root`.
+func walkDirPaths(t *testing.T, root string, m DirIgnoreParser) []string {
+	var paths []string
+	err := WalkDir(root, m, func(fpath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(root, fpath)
+		paths = append(paths, filepath.ToSlash(rel))
+		return nil
+	})
+	assert.Nil(t, err, "err should be nil")
+	sort.Strings(paths)
+	return paths
+}
+
+////////////////////////////////////////////////////////////
+
+// Validate that ignored directories are pruned, and that nothing below them
+// is visited even if a later pattern would re-include it
+func TestWalkDir_PrunesIgnoredDirectories(t *testing.T) {
+	root := writeTestTree(t, map[string]string{
+		"main.go":               "",
+		"debug.log":             "",
+		"build/out.bin":         "",
+		"build/keep.txt":        "",
+		"src/lib.go":            "",
+		"src/node_modules/a.js": "",
+	})
+	defer os.RemoveAll(root)
+
+	object := CompileIgnoreLines("*.log", "build/", "!build/keep.txt", "node_modules")
+	assert.Equal(t, []string{".", "main.go", "src", "src/lib.go"}, walkDirPaths(t, root, object))
+}
+
+// Validate that the directory flag is passed to the matcher
+func TestWalkDir_DirectoryFlag(t *testing.T) {
+	root := writeTestTree(t, map[string]string{
+		"bin":          "",
+		"pkg/bin/tool": "",
+		"pkg/main.go":  "",
+	})
+	defer os.RemoveAll(root)
+
+	object := CompileIgnoreLines("bin/")
+	assert.Equal(t, []string{".", "bin", "pkg", "pkg/main.go"}, walkDirPaths(t, root, object))
+}
+
+// Validate that nested ignore files are discovered when no matcher is given
+func TestWalkDir_DiscoversIgnoreFiles(t *testing.T) {
+	root := writeTestTree(t, map[string]string{
+		".gitignore":          "*.log\n",
+		".git/config":         "",
+		"debug.log":           "",
+		"api/.gitignore":      "/local.txt\n!keep.log\n",
+		"api/local.txt":       "",
+		"api/keep.log":        "",
+		"api/pkg/local.txt":   "",
+		"web/.gitignore":      "dist/\n",
+		"web/dist/app.js":     "",
+		"web/dist/.gitignore": "!app.js\n",
+		"web/src/app.js":      "",
+	})
+	defer os.RemoveAll(root)
+
+	expected := []string{
+		".", ".gitignore",
+		"api", "api/.gitignore", "api/keep.log", "api/pkg", "api/pkg/local.txt",
+		"web", "web/.gitignore", "web/src", "web/src/app.js",
+	}
+	assert.Equal(t, expected, walkDirPaths(t, root, nil))
+}
+
+func TestWalkDir_InvalidRoot(t *testing.T) {
+	var visited []string
+	err := WalkDir("./test_fixtures/invalid.dir", nil, func(fpath string, d fs.DirEntry, err error) error {
+		visited = append(visited, fpath)
+		return err
+	})
+	assert.NotNil(t, err, "err should be unknown file / dir")
+	assert.Equal(t, []string{"./test_fixtures/invalid.dir"}, visited, "fn should see the error")
+}
+
+func TestWalk(t *testing.T) {
+	root := writeTestTree(t, map[string]string{
+		".gitignore":  "vendor/\n*.tmp\n",
+		"a.go":        "",
+		"a.tmp":       "",
+		"vendor/x.go": "",
+	})
+	defer os.RemoveAll(root)
+
+	var paths []string
+	err := Walk(root, nil, func(fpath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(root, fpath)
+		paths = append(paths, filepath.ToSlash(rel))
+		return nil
+	})
+	assert.Nil(t, err, "err should be nil")
+	assert.Equal(t, []string{".", ".gitignore", "a.go"}, paths)
+}
+
+func TestWalkDirFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		".gitignore":      {Data: []byte("*.log\n")},
+		".git/HEAD":       {},
+		"debug.log":       {},
+		"web/.gitignore":  {Data: []byte("dist/\n!keep.log\n")},
+		"web/dist/app.js": {},
+		"web/keep.log":    {},
+		"web/src/app.js":  {},
+	}
+
+	var paths []string
+	err := WalkDirFS(fsys, ".", nil, func(fpath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		paths = append(paths, fpath)
+		return nil
+	})
+	assert.Nil(t, err, "err should be nil")
+	assert.Equal(t, []string{".", ".gitignore", "web", "web/.gitignore", "web/keep.log", "web/src", "web/src/app.js"}, paths)
+
+	paths = nil
+	object := CompileIgnoreLines("src")
+	err = WalkDirFS(fsys, "web", object, func(fpath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		paths = append(paths, fpath)
+		return nil
+	})
+	assert.Nil(t, err, "err should be nil")
+	assert.Equal(t, []string{"web", "web/.gitignore", "web/dist", "web/dist/app.js", "web/keep.log"}, paths)
+}
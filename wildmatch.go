@@ -0,0 +1,267 @@
+package ignore
+
+import (
+	"strings"
+)
+
+////////////////////////////////////////////////////////////
+
+// Flags for wildmatch.
+const (
+	// wmPathname makes "*" and "?" stop at a "/", and gives "**" its special
+	// meaning when it makes up a whole path component [Rule 9].
+	wmPathname = 1 << iota
+
+	// wmCaseFold makes the matching ignore the case of ASCII letters, for
+	// literals, wildcards and bracket expressions alike.
+	wmCaseFold
+)
+
+// Return values of dowild. wmAbortAll and wmAbortToStarStar let a "*" give up
+// early when the rest of the text can not possibly match.
+const (
+	wmMatch = iota
+	wmNoMatch
+	wmAbortAll
+	wmAbortToStarStar
+)
+
+////////////////////////////////////////////////////////////
+
+// wildmatch reports whether the shell glob `pattern` matches all of `text`.
+// It is a port of git's wildmatch.c, and works on bytes without allocating.
+// A pattern with an invalid bracket expression never matches.
+func wildmatch(pattern, text string, flags int) bool {
+	return dowild(pattern, text, flags) == wmMatch
+}
+
+// charAt returns the byte at `s[i]`, or 0 past the end of `s`. This mirrors
+// the NUL terminated strings the algorithm was written for.
+func charAt(s string, i int) byte {
+	if i < len(s) {
+		return s[i]
+	}
+	return 0
+}
+
+// fold returns the lower case of the ASCII letter `c` if `flags` have
+// wmCaseFold, and `c` otherwise.
+func fold(c byte, flags int) byte {
+	if flags&wmCaseFold != 0 && isUpper(c) {
+		return c + 'a' - 'A'
+	}
+	return c
+}
+
+// isGlobSpecial reports whether `c` has a special meaning in a glob.
+func isGlobSpecial(c byte) bool {
+	return c == '*' || c == '?' || c == '[' || c == '\\'
+}
+
+func dowild(p, text string, flags int) int {
+	pi, ti := 0, 0
+	for ; pi < len(p); pi, ti = pi+1, ti+1 {
+		pCh, tCh := fold(p[pi], flags), fold(charAt(text, ti), flags)
+		if tCh == 0 && pCh != '*' {
+			return wmAbortAll
+		}
+
+		switch pCh {
+		case '\\':
+			// Literal match with the following character. A trailing
+			// backslash is compared as a 0, and so never matches.
+			pi++
+			if tCh != fold(charAt(p, pi), flags) {
+				return wmNoMatch
+			}
+
+		default:
+			if tCh != pCh {
+				return wmNoMatch
+			}
+
+		case '?':
+			// Match anything but "/".
+			if flags&wmPathname != 0 && tCh == '/' {
+				return wmNoMatch
+			}
+
+		case '*':
+			var matchSlash bool
+			pi++
+			if charAt(p, pi) == '*' {
+				prev := pi - 2
+				for pi++; charAt(p, pi) == '*'; pi++ {
+				}
+				if flags&wmPathname != 0 &&
+					(prev < 0 || p[prev] == '/') &&
+					(pi == len(p) || p[pi] == '/' || (p[pi] == '\\' && charAt(p, pi+1) == '/')) {
+					// Assuming we already match "foo/" and are at "**/",
+					// just assume it matches nothing and go ahead match
+					// the rest of the pattern with the remaining text.
+					// This makes "foo/**/bar" match both "foo/bar" and
+					// "foo/a/bar".
+					if charAt(p, pi) == '/' && dowild(p[pi+1:], text[ti:], flags) == wmMatch {
+						return wmMatch
+					}
+					matchSlash = true
+				} else {
+					// [Rule 9.iv] other consecutive asterisks are a "*".
+					matchSlash = flags&wmPathname == 0
+				}
+			} else {
+				// Without wmPathname, "*" is the same as "**".
+				matchSlash = flags&wmPathname == 0
+			}
+
+			if pi == len(p) {
+				// A trailing "**" matches everything. A trailing "*"
+				// matches only if there are no more slash characters.
+				if !matchSlash && strings.IndexByte(text[ti:], '/') >= 0 {
+					return wmNoMatch
+				}
+				return wmMatch
+			} else if !matchSlash && p[pi] == '/' {
+				// A single asterisk followed by a slash with wmPathname
+				// matches the next directory.
+				slash := strings.IndexByte(text[ti:], '/')
+				if slash < 0 {
+					return wmNoMatch
+				}
+				// The slash is consumed by the loop.
+				ti += slash
+				continue
+			}
+
+			for tCh != 0 {
+				// Try to advance faster when an asterisk is followed by
+				// a literal. We know in this case that the text before
+				// the literal must belong to the asterisk. Unless it can
+				// match slashes, do not look past the first one.
+				if !isGlobSpecial(p[pi]) {
+					pCh = fold(p[pi], flags)
+					for tCh = fold(charAt(text, ti), flags); tCh != 0 && (matchSlash || tCh != '/'); tCh = fold(charAt(text, ti), flags) {
+						if tCh == pCh {
+							break
+						}
+						ti++
+					}
+					if tCh != pCh {
+						return wmNoMatch
+					}
+				}
+				if matched := dowild(p[pi:], text[ti:], flags); matched != wmNoMatch {
+					if !matchSlash || matched != wmAbortToStarStar {
+						return matched
+					}
+				} else if !matchSlash && tCh == '/' {
+					return wmAbortToStarStar
+				}
+				ti++
+				tCh = fold(charAt(text, ti), flags)
+			}
+			return wmAbortAll
+
+		case '[':
+			pi++
+			pCh = charAt(p, pi)
+			if pCh == '^' {
+				pCh = '!'
+			}
+			negated := pCh == '!'
+			if negated {
+				// Inverted character class.
+				pi++
+				pCh = charAt(p, pi)
+			}
+
+			var prevCh byte
+			matched := false
+			for {
+				if pCh == 0 {
+					return wmAbortAll
+				}
+				switch {
+				case pCh == '\\':
+					pi++
+					pCh = charAt(p, pi)
+					if pCh == 0 {
+						return wmAbortAll
+					}
+					if tCh == fold(pCh, flags) {
+						matched = true
+					}
+
+				case pCh == '-' && prevCh != 0 && charAt(p, pi+1) != 0 && p[pi+1] != ']':
+					pi++
+					pCh = p[pi]
+					if pCh == '\\' {
+						pi++
+						pCh = charAt(p, pi)
+						if pCh == 0 {
+							return wmAbortAll
+						}
+					}
+					if tCh <= pCh && tCh >= prevCh {
+						matched = true
+					} else if flags&wmCaseFold != 0 && isLower(tCh) {
+						// The text was folded to lower case, so also
+						// try its upper case against ranges such as
+						// "A-Z".
+						if upper := tCh - 'a' + 'A'; upper <= pCh && upper >= prevCh {
+							matched = true
+						}
+					}
+					// This makes prevCh get set to 0.
+					pCh = 0
+
+				case pCh == '[' && charAt(p, pi+1) == ':':
+					start := pi + 2
+					end := strings.IndexByte(p[start:], ']')
+					if end < 0 {
+						return wmAbortAll
+					}
+					end += start
+					if end == start || p[end-1] != ':' {
+						// Didn't find ":]", so treat it like a normal set.
+						if tCh == '[' {
+							matched = true
+						}
+						break
+					}
+					isMember, ok := posixClasses[p[start:end-1]]
+					if !ok {
+						// Malformed [:class:] string.
+						return wmAbortAll
+					}
+					if isMember(tCh) || (flags&wmCaseFold != 0 && p[start:end-1] == "upper" && isLower(tCh)) {
+						matched = true
+					}
+					// This makes prevCh get set to 0.
+					pi, pCh = end, 0
+
+				default:
+					if tCh == fold(pCh, flags) {
+						matched = true
+					}
+				}
+
+				prevCh = pCh
+				pi++
+				if pCh = charAt(p, pi); pCh == ']' {
+					break
+				}
+			}
+			if matched == negated || (flags&wmPathname != 0 && tCh == '/') {
+				return wmNoMatch
+			}
+		}
+	}
+
+	if ti < len(text) {
+		return wmNoMatch
+	}
+	return wmMatch
+}
+
+////////////////////////////////////////////////////////////
@@ -0,0 +1,254 @@
+// Implement tests for `wildmatch`, ported from git's t/t3070-wildmatch.sh
+package ignore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+////////////////////////////////////////////////////////////
+
+// Each case lists whether `text` matches `glob` with the wmPathname flag
+// (the "glob" column of t3070), and without any flag ("pathmatch").
+var wildmatchCases = []struct {
+	glob, pathmatch bool
+	text, pattern   string
+}{
+	// Basic wildmatch features
+	{true, true, "foo", "foo"},
+	{false, false, "foo", "bar"},
+	{true, true, "", ""},
+	{true, true, "foo", "???"},
+	{false, false, "foo", "??"},
+	{true, true, "foo", "*"},
+	{true, true, "foo", "f*"},
+	{false, false, "foo", "*f"},
+	{true, true, "foo", "*foo*"},
+	{true, true, "foobar", "*ob*a*r*"},
+	{true, true, "aaaaaaabababab", "*ab"},
+	{true, true, "foo*", `foo\*`},
+	{false, false, "foobar", `foo\*bar`},
+	{true, true, `f\oo`, `f\\oo`},
+	{true, true, "ball", "*[al]?"},
+	{false, false, "ten", "[ten]"},
+	{true, true, "ten", "**[!te]"},
+	{false, false, "ten", "**[!ten]"},
+	{true, true, "ten", "t[a-g]n"},
+	{false, false, "ten", "t[!a-g]n"},
+	{true, true, "ton", "t[!a-g]n"},
+	{true, true, "ton", "t[^a-g]n"},
+	{true, true, "a]b", "a[]]b"},
+	{true, true, "a-b", "a[]-]b"},
+	{true, true, "a]b", "a[]-]b"},
+	{false, false, "aab", "a[]-]b"},
+	{true, true, "aab", "a[]a-]b"},
+	{true, true, "]", "]"},
+
+	// Extended slash-matching features
+	{false, true, "foo/baz/bar", "foo*bar"},
+	{false, true, "foo/baz/bar", "foo**bar"},
+	{true, true, "foobazbar", "foo**bar"},
+	{true, true, "foo/baz/bar", "foo/**/bar"},
+	{true, false, "foo/baz/bar", "foo/**/**/bar"},
+	{true, true, "foo/b/a/z/bar", "foo/**/bar"},
+	{true, true, "foo/b/a/z/bar", "foo/**/**/bar"},
+	{true, false, "foo/bar", "foo/**/bar"},
+	{true, false, "foo/bar", "foo/**/**/bar"},
+	{false, true, "foo/bar", "foo?bar"},
+	{false, true, "foo/bar", "foo[/]bar"},
+	{false, true, "foo/bar", "foo[^a-z]bar"},
+	{false, true, "foo/bar", "f[^eiu][^eiu][^eiu][^eiu][^eiu]r"},
+	{true, true, "foo-bar", "f[^eiu][^eiu][^eiu][^eiu][^eiu]r"},
+	{true, false, "foo", "**/foo"},
+	{true, true, "XXX/foo", "**/foo"},
+	{true, true, "bar/baz/foo", "**/foo"},
+	{false, true, "bar/baz/foo", "*/foo"},
+	{false, true, "foo/bar/baz", "**/bar*"},
+	{true, true, "deep/foo/bar/baz", "**/bar/*"},
+	{false, true, "deep/foo/bar/baz/", "**/bar/*"},
+	{true, true, "deep/foo/bar/baz/", "**/bar/**"},
+	{false, false, "deep/foo/bar", "**/bar/*"},
+	{true, true, "deep/foo/bar/", "**/bar/**"},
+	{false, true, "foo/bar/baz", "**/bar**"},
+	{true, true, "foo/bar/baz/x", "*/bar/**"},
+	{false, true, "deep/foo/bar/baz/x", "*/bar/**"},
+	{true, true, "deep/foo/bar/baz/x", "**/bar/*/*"},
+
+	// Various additional tests
+	{false, false, "acrt", "a[c-c]st"},
+	{true, true, "acrt", "a[c-c]rt"},
+	{false, false, "]", "[!]-]"},
+	{true, true, "a", "[!]-]"},
+	{false, false, "", `\`},
+	{false, false, `\`, `\`},
+	{false, false, "XXX/\\", `*/\`},
+	{true, true, "XXX/\\", `*/\\`},
+	{true, true, "foo", "foo"},
+	{true, true, "@foo", "@foo"},
+	{false, false, "foo", "@foo"},
+	{true, true, "[ab]", `\[ab]`},
+	{true, true, "[ab]", "[[]ab]"},
+	{true, true, "[ab]", "[[:]ab]"},
+	{false, false, "[ab]", "[[::]ab]"},
+	{true, true, "[ab]", "[[:digit]ab]"},
+	{true, true, "[ab]", `[\[:]ab]`},
+	{true, true, "?a?b", `\??\?b`},
+	{true, true, "abc", `\a\b\c`},
+	{false, false, "foo", ""},
+	{true, true, "foo/bar/baz/to", "**/t[o]"},
+
+	// Character class tests
+	{true, true, "a1B", "[[:alpha:]][[:digit:]][[:upper:]]"},
+	{false, false, "a", "[[:digit:][:upper:][:space:]]"},
+	{true, true, "A", "[[:digit:][:upper:][:space:]]"},
+	{true, true, "1", "[[:digit:][:upper:][:space:]]"},
+	{false, false, "1", "[[:digit:][:upper:][:spaci:]]"},
+	{true, true, " ", "[[:digit:][:upper:][:space:]]"},
+	{false, false, ".", "[[:digit:][:upper:][:space:]]"},
+	{true, true, ".", "[[:digit:][:punct:][:space:]]"},
+	{true, true, "5", "[[:xdigit:]]"},
+	{true, true, "f", "[[:xdigit:]]"},
+	{true, true, "D", "[[:xdigit:]]"},
+	{true, true, "5", "[a-c[:digit:]x-z]"},
+	{false, false, "q", "[a-c[:digit:]x-z]"},
+
+	// Additional tests, including some malformed wildmatch patterns
+	{true, true, "]", `[\\-^]`},
+	{false, false, "[", `[\\-^]`},
+	{true, true, "-", `[\-_]`},
+	{true, true, "]", `[\]]`},
+	{false, false, `\]`, `[\]]`},
+	{false, false, `\`, `[\]]`},
+	{false, false, "ab", "a[]b"},
+	{false, false, "a[]b", "a[]b"},
+	{false, false, "ab[", "ab["},
+	{false, false, "ab", "[!"},
+	{false, false, "ab", "[-"},
+	{true, true, "-", "[-]"},
+	{false, false, "-", "[a-"},
+	{false, false, "-", "[!a-"},
+	{true, true, "-", "[--A]"},
+	{true, true, "5", "[--A]"},
+	{true, true, " ", "[ --]"},
+	{true, true, "$", "[ --]"},
+	{true, true, "-", "[ --]"},
+	{false, false, "0", "[ --]"},
+	{true, true, "-", "[---]"},
+	{true, true, "-", "[------]"},
+	{false, false, "j", "[a-e-n]"},
+	{true, true, "-", "[a-e-n]"},
+	{true, true, "a", "[!------]"},
+	{false, false, "[", "[]-a]"},
+	{true, true, "^", "[]-a]"},
+	{false, false, "^", "[!]-a]"},
+	{true, true, "[", "[!]-a]"},
+	{true, true, "^", "[a^bc]"},
+	{true, true, "-b]", "[a-]b]"},
+	{false, false, `\`, `[\]`},
+	{true, true, `\`, `[\\]`},
+	{false, false, `\`, `[!\\]`},
+	{true, true, "G", `[A-\\]`},
+	{false, false, "aaabbb", "b*a"},
+	{false, false, "aabcaa", "*ba*"},
+	{true, true, ",", "[,]"},
+	{true, true, ",", `[\\,]`},
+	{true, true, `\`, `[\\,]`},
+	{true, true, "-", "[,-.]"},
+	{false, false, "+", "[,-.]"},
+	{false, false, "-.]", "[,-.]"},
+	{true, true, "2", `[\1-\3]`},
+	{true, true, "3", `[\1-\3]`},
+	{false, false, "4", `[\1-\3]`},
+	{true, true, `\`, `[[-\]]`},
+	{true, true, "[", `[[-\]]`},
+	{true, true, "]", `[[-\]]`},
+	{false, false, "-", `[[-\]]`},
+
+	// Test recursion
+	{true, true, "-adobe-courier-bold-o-normal--12-120-75-75-m-70-iso8859-1", "-*-*-*-*-*-*-12-*-*-*-m-*-*-*"},
+	{false, false, "-adobe-courier-bold-o-normal--12-120-75-75-X-70-iso8859-1", "-*-*-*-*-*-*-12-*-*-*-m-*-*-*"},
+	{false, false, "-adobe-courier-bold-o-normal--12-120-75-75-/-70-iso8859-1", "-*-*-*-*-*-*-12-*-*-*-m-*-*-*"},
+	{true, true, "XXX/adobe/courier/bold/o/normal//12/120/75/75/m/70/iso8859/1", "XXX/*/*/*/*/*/*/12/*/*/*/m/*/*/*"},
+	{false, false, "XXX/adobe/courier/bold/o/normal//12/120/75/75/X/70/iso8859/1", "XXX/*/*/*/*/*/*/12/*/*/*/m/*/*/*"},
+	{true, true, "abcd/abcdefg/abcdefghijk/abcdefghijklmnop.txt", "**/*a*b*g*n*t"},
+	{false, false, "abcd/abcdefg/abcdefghijk/abcdefghijklmnop.txtz", "**/*a*b*g*n*t"},
+	{false, false, "foo", "*/*/*"},
+	{false, false, "foo/bar", "*/*/*"},
+	{true, true, "foo/bba/arr", "*/*/*"},
+	{false, true, "foo/bb/aa/rr", "*/*/*"},
+	{true, true, "foo/bb/aa/rr", "**/**/**"},
+	{true, true, "abcXdefXghi", "*X*i"},
+	{false, true, "ab/cXd/efXg/hi", "*X*i"},
+	{true, true, "ab/cXd/efXg/hi", "*/*X*/*/*i"},
+	{true, true, "ab/cXd/efXg/hi", "**/*X*/**/*i"},
+}
+
+func TestWildmatch(t *testing.T) {
+	for _, c := range wildmatchCases {
+		assert.Equal(t, c.glob, wildmatch(c.pattern, c.text, wmPathname), "glob %q against %q", c.pattern, c.text)
+		assert.Equal(t, c.pathmatch, wildmatch(c.pattern, c.text, 0), "pathmatch %q against %q", c.pattern, c.text)
+	}
+}
+
+// Validate that the compatibility regex agrees with wildmatch
+func TestWildmatch_AgreesWithRegexp(t *testing.T) {
+	for _, c := range wildmatchCases {
+		body, err := globToRegexp(c.pattern)
+		if err != nil || c.text == "" {
+			continue
+		}
+		ip, err := parsePatternLine("/" + c.pattern)
+		if err != nil || ip == nil || ip.glob != c.pattern {
+			continue
+		}
+		if err := ip.compileRegexp(); err != nil {
+			continue
+		}
+		assert.Equal(t, ip.Pattern.MatchString(c.text), ip.matches(c.text, false),
+			"pattern %q (%s) against %q", c.pattern, body, c.text)
+	}
+}
+
+// Validate that matching a path does not allocate
+func TestWildmatch_NoAllocations(t *testing.T) {
+	object := CompileIgnoreLines("*.o", "/build/**/tmp", "[Bb]in/", "!keep.o")
+	allocs := testing.AllocsPerRun(100, func() {
+		object.MatchesPath("src/pkg/build/deep/main.o")
+		object.MatchesPathIsDir("build/a/b/tmp", true)
+	})
+	assert.Equal(t, float64(0), allocs, "matching should not allocate")
+}
+
+// Each case lists whether `text` matches `pattern` with and without the
+// wmCaseFold flag (the "iwildmatch" and "wildmatch" columns of t3070).
+var wildmatchCaseFoldCases = []struct {
+	casefold, exact bool
+	text, pattern   string
+}{
+	{true, false, "a", "[A-Z]"},
+	{true, true, "A", "[A-Z]"},
+	{true, false, "A", "[a-z]"},
+	{true, true, "a", "[a-z]"},
+	{true, false, "a", "[[:upper:]]"},
+	{true, true, "A", "[[:upper:]]"},
+	{true, false, "A", "[[:lower:]]"},
+	{true, true, "a", "[[:lower:]]"},
+	{true, false, "A", "[B-Za]"},
+	{true, true, "a", "[B-Za]"},
+	{true, false, "A", "[B-a]"},
+	{true, true, "a", "[B-a]"},
+	{true, false, "z", "[Z-y]"},
+	{true, true, "Z", "[Z-y]"},
+	{true, false, "FOO/Bar.TXT", "foo/*.txt"},
+	{true, false, "Foo/a/BAR", "foo/**/bar"},
+	{true, false, "foo", `f\O?`},
+	{false, false, "foo/bar", "FOO?BAR"},
+}
+
+func TestWildmatch_CaseFold(t *testing.T) {
+	for _, c := range wildmatchCaseFoldCases {
+		assert.Equal(t, c.casefold, wildmatch(c.pattern, c.text, wmPathname|wmCaseFold), "casefold %q against %q", c.pattern, c.text)
+		assert.Equal(t, c.exact, wildmatch(c.pattern, c.text, wmPathname), "exact %q against %q", c.pattern, c.text)
+	}
+}